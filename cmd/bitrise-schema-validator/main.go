@@ -0,0 +1,454 @@
+// Command bitrise-schema-validator validates bitrise.yml, step.yml and StepLib spec files
+// against the JSON schemas of this module.
+//
+// Usage:
+//
+//	bitrise-schema-validator [--schema bitrise|step|steplib|steplib-slim] [--warning-pattern <regex>]... [--format text|human|sarif] [--fix]
+//		[--include-root <dir>] [--include-checkout <repository>=<dir>]... <file>...
+//	bitrise-schema-validator --graph pipeline:<name>|workflow:<name> [--graph-format dot|mermaid] <bitrise.yml>...
+//
+// A bitrise.yml with include items is validated merged with the files it includes. The include paths are relative
+// to --include-root, which defaults to the directory of the bitrise.yml. Files of other repositories are read
+// from their local checkouts given by --include-checkout.
+//
+// With --fix the mechanical fixes of step.yml files are applied in place before reporting
+// the remaining issues. Comments and key order are kept. Other files are only validated.
+//
+// With --graph the diagram of a pipeline, or of the workflows a workflow runs through its before_run and after_run
+// workflows, is printed as Graphviz DOT or Mermaid text instead of validating the files.
+//
+// The exit code is 1 if any of the files has validation errors and 2 if the files couldn't be validated.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+	"github.com/bitrise-io/bitrise-json-schemas/validator"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	formatText  = "text"
+	formatHuman = "human"
+	formatSARIF = "sarif"
+)
+
+const (
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+)
+
+const (
+	exitCodeOK               = 0
+	exitCodeValidationFailed = 1
+	exitCodeError            = 2
+)
+
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("bitrise-schema-validator", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	schemaFlag := flags.String("schema", "", "Schema to validate against: bitrise, step, steplib or steplib-slim (detected from the file if not set)")
+	var warningPatterns stringSliceFlag
+	flags.Var(&warningPatterns, "warning-pattern", "Regex matched against the issues, matching issues are reported as warnings (repeatable)")
+	format := flags.String("format", formatText, "Output format: text, human or sarif")
+	fix := flags.Bool("fix", false, "Apply the mechanical fixes to the step.yml files in place, then report the remaining issues")
+	includeRoot := flags.String("include-root", "", "Directory the include paths of bitrise.yml files are relative to (the directory of the bitrise.yml if not set)")
+	var includeCheckouts stringSliceFlag
+	flags.Var(&includeCheckouts, "include-checkout", "Local checkout of a repository included by bitrise.yml files, as <repository>=<dir> (repeatable)")
+	graph := flags.String("graph", "", "Print the diagram of a pipeline or a workflow chain instead of validating, as pipeline:<name> or workflow:<name>")
+	graphFormat := flags.String("graph-format", graphFormatDOT, "Diagram format: dot or mermaid")
+	if err := flags.Parse(args); err != nil {
+		return exitCodeError
+	}
+	if *graph != "" {
+		return exportGraphs(flags.Args(), *graph, *graphFormat, stdout, stderr)
+	}
+	if *format != formatText && *format != formatHuman && *format != formatSARIF {
+		fmt.Fprintf(stderr, "unknown output format: %s\n", *format)
+		return exitCodeError
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "no files to validate")
+		flags.Usage()
+		return exitCodeError
+	}
+
+	warningRules, err := validator.NewWarningRules(warningPatterns...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCodeError
+	}
+	checkouts := map[string]string{}
+	for _, checkout := range includeCheckouts {
+		idx := strings.LastIndex(checkout, "=")
+		if idx <= 0 || idx == len(checkout)-1 {
+			fmt.Fprintf(stderr, "invalid include checkout %q, expected <repository>=<dir>\n", checkout)
+			return exitCodeError
+		}
+		checkouts[checkout[:idx]] = checkout[idx+1:]
+	}
+	cfg := config{
+		kind:         schemas.Kind(*schemaFlag),
+		warningRules: warningRules,
+		fix:          *fix,
+		includeRoot:  *includeRoot,
+		checkouts:    checkouts,
+	}
+
+	validators := map[schemas.Kind]*validator.JSONSchemaValidator{}
+	reporter := validator.NewSARIFReporter()
+	exitCode := exitCodeOK
+	for _, pth := range flags.Args() {
+		result, err := validateFile(pth, cfg, validators)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", pth, err)
+			exitCode = exitCodeError
+			continue
+		}
+
+		if *format != formatSARIF {
+			for _, change := range result.changes {
+				fmt.Fprintf(stdout, "%s: fixed: %s\n", changeLocation(pth, change), change.Description)
+			}
+		}
+		for _, issue := range result.issues {
+			if issue.Severity == validator.SeverityError && exitCode == exitCodeOK {
+				exitCode = exitCodeValidationFailed
+			}
+			issuePth := pth
+			if issue.File != "" {
+				issuePth = issue.File
+			}
+			switch *format {
+			case formatText:
+				fmt.Fprintf(stdout, "%s: %s: %s%s\n", issueLocation(issuePth, issue), issue.Severity, issue, issueDefinition(issuePth, issue))
+			case formatHuman:
+				fmt.Fprintf(stdout, "%s: %s: %s%s\n", issueLocation(issuePth, issue), issue.Severity, messageRenderer(result.kind).Render(issue), issueDefinition(issuePth, issue))
+			case formatSARIF:
+				reporter.Add(filepath.ToSlash(issuePth), []validator.ValidationIssue{issue})
+			}
+		}
+	}
+
+	if *format == formatSARIF {
+		if err := reporter.Write(stdout); err != nil {
+			fmt.Fprintf(stderr, "failed to write SARIF log: %s\n", err)
+			return exitCodeError
+		}
+	}
+
+	return exitCode
+}
+
+type config struct {
+	kind         schemas.Kind
+	warningRules validator.WarningRules
+	fix          bool
+	includeRoot  string
+	checkouts    map[string]string
+}
+
+type fileResult struct {
+	kind    schemas.Kind
+	issues  []validator.ValidationIssue
+	changes []validator.FixChange
+}
+
+// validateFile validates the file against the schema of the configured kind, or the detected one if it is not set.
+// The issues are sorted by their position, as the validator reports them in no particular order.
+// If fix is set, the step.yml fixes are applied to step.yml files, and the fixed file is written back.
+func validateFile(pth string, cfg config, validators map[schemas.Kind]*validator.JSONSchemaValidator) (*fileResult, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %s", err)
+	}
+
+	kind := cfg.kind
+	if kind == "" {
+		kind, err = detectSchemaKind(pth, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	v, ok := validators[kind]
+	if !ok {
+		schema, err := schemas.Get(kind)
+		if err != nil {
+			return nil, err
+		}
+		opts := []validator.Option{validator.WithWarningRules(cfg.warningRules)}
+		if kind == schemas.KindBitriseYML {
+			opts = append(opts, validator.WithSemanticChecks(validator.BitriseYMLSemanticChecks()...))
+		}
+		v, err = validator.NewJSONSchemaValidator(schema, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %s schema: %s", kind, err)
+		}
+		validators[kind] = v
+	}
+
+	result := &fileResult{kind: kind}
+	isJSON := strings.EqualFold(filepath.Ext(pth), ".json")
+	modular := !isJSON && kind == schemas.KindBitriseYML && hasIncludes(content)
+	switch {
+	case isJSON:
+		result.issues, err = v.ValidateJSON(content)
+	case cfg.fix && kind == schemas.KindStepYML:
+		var fixResult *validator.FixResult
+		fixResult, err = validator.NewFixer(v, validator.StepYMLFixes()...).Fix(string(content))
+		if err == nil {
+			result.issues, result.changes = fixResult.Issues, fixResult.Changes
+			if len(fixResult.Changes) > 0 {
+				content = []byte(fixResult.Fixed)
+				if err := writeFile(pth, content); err != nil {
+					return nil, fmt.Errorf("failed to write the fixed file: %s", err)
+				}
+			}
+		}
+	case !modular:
+		result.issues, err = v.ValidateDocuments(string(content))
+	}
+	if err == nil && modular {
+		result.issues, err = v.ValidateModular(pth, string(content), includeResolver(pth, cfg))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %s", err)
+	}
+	sortIssues(result.issues)
+
+	return result, nil
+}
+
+// exportGraphs prints the diagram of the pipeline or workflow chain of each bitrise.yml.
+func exportGraphs(paths []string, graph, format string, stdout, stderr io.Writer) int {
+	if format != graphFormatDOT && format != graphFormatMermaid {
+		fmt.Fprintf(stderr, "unknown graph format: %s\n", format)
+		return exitCodeError
+	}
+	idx := strings.Index(graph, ":")
+	if idx == -1 || (graph[:idx] != "pipeline" && graph[:idx] != "workflow") || idx == len(graph)-1 {
+		fmt.Fprintf(stderr, "invalid graph %q, expected pipeline:<name> or workflow:<name>\n", graph)
+		return exitCodeError
+	}
+	kind, name := graph[:idx], graph[idx+1:]
+
+	exitCode := exitCodeOK
+	for _, pth := range paths {
+		diagram, err := fileDiagram(pth, kind, name)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", pth, err)
+			exitCode = exitCodeError
+			continue
+		}
+		if format == graphFormatMermaid {
+			fmt.Fprint(stdout, diagram.Mermaid())
+		} else {
+			fmt.Fprint(stdout, diagram.DOT())
+		}
+	}
+	return exitCode
+}
+
+func fileDiagram(pth, kind, name string) (*validator.Diagram, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %s", err)
+	}
+	stream, err := validator.ParseYAML(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if kind == "pipeline" {
+		return validator.PipelineDiagram(stream.Documents[0].Value, name)
+	}
+	return validator.WorkflowChainDiagram(stream.Documents[0].Value, name)
+}
+
+func hasIncludes(content []byte) bool {
+	var document struct {
+		Include []interface{} `yaml:"include"`
+	}
+	return yaml.Unmarshal(content, &document) == nil && len(document.Include) > 0
+}
+
+func includeResolver(pth string, cfg config) validator.IncludeResolver {
+	root := cfg.includeRoot
+	if root == "" {
+		root = filepath.Dir(pth)
+	}
+	return validator.GitCheckoutIncludeResolver{
+		Checkouts: cfg.checkouts,
+		Local:     validator.FileIncludeResolver{Root: root},
+	}
+}
+
+// writeFile overwrites the file, keeping its permissions.
+func writeFile(pth string, content []byte) error {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pth, content, info.Mode().Perm())
+}
+
+func messageRenderer(kind schemas.Kind) *validator.MessageRenderer {
+	switch kind {
+	case schemas.KindBitriseYML:
+		return validator.NewBitriseYMLMessageRenderer()
+	case schemas.KindStepYML:
+		return validator.NewStepYMLMessageRenderer()
+	}
+	return &validator.MessageRenderer{}
+}
+
+func issueLocation(pth string, issue validator.ValidationIssue) string {
+	if issue.Line == 0 {
+		return pth
+	}
+	return fmt.Sprintf("%s:%d:%d", pth, issue.Line, issue.Column)
+}
+
+// issueDefinition tells where the offending value is defined, if it is used through a YAML alias or merge key.
+func issueDefinition(pth string, issue validator.ValidationIssue) string {
+	if issue.DefinitionLine == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n  defined at: %s:%d:%d", pth, issue.DefinitionLine, issue.DefinitionColumn)
+}
+
+func changeLocation(pth string, change validator.FixChange) string {
+	if change.Line == 0 {
+		return pth
+	}
+	return fmt.Sprintf("%s:%d:%d", pth, change.Line, change.Column)
+}
+
+// detectSchemaKind picks the schema by the file name and falls back to inspecting the top level keys of the document.
+func detectSchemaKind(pth string, content []byte) (schemas.Kind, error) {
+	name := strings.ToLower(filepath.Base(pth))
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	switch {
+	case (ext == ".yml" || ext == ".yaml") && (base == "bitrise" || strings.HasSuffix(base, ".bitrise")):
+		return schemas.KindBitriseYML, nil
+	case (ext == ".yml" || ext == ".yaml") && base == "step":
+		return schemas.KindStepYML, nil
+	case ext == ".json" && (base == "slim-spec" || base == "spec-slim"):
+		return schemas.KindStepLibSlimSpec, nil
+	case ext == ".json" && base == "spec":
+		return schemas.KindStepLibSpec, nil
+	}
+
+	var document map[string]interface{}
+	if ext == ".json" {
+		if err := json.Unmarshal(content, &document); err != nil {
+			return "", fmt.Errorf("failed to detect schema: %s", err)
+		}
+	} else {
+		var m map[interface{}]interface{}
+		if err := yaml.Unmarshal(content, &m); err != nil {
+			return "", fmt.Errorf("failed to detect schema: %s", err)
+		}
+		document = map[string]interface{}{}
+		for key, value := range m {
+			document[fmt.Sprint(key)] = value
+		}
+	}
+
+	has := func(key string) bool {
+		_, ok := document[key]
+		return ok
+	}
+	switch {
+	case has("steplib_source") && isSlimStepLibSpec(document["steps"]):
+		return schemas.KindStepLibSlimSpec, nil
+	case has("steplib_source"):
+		return schemas.KindStepLibSpec, nil
+	case has("format_version") || has("workflows") || has("pipelines") || has("include"):
+		return schemas.KindBitriseYML, nil
+	case has("title") && has("summary"):
+		return schemas.KindStepYML, nil
+	}
+
+	return "", fmt.Errorf("failed to detect schema, use the --schema flag")
+}
+
+// isSlimStepLibSpec tells if the steps of a StepLib spec are in the slim form:
+// none of the steps have the latest_version_number the full spec requires.
+func isSlimStepLibSpec(steps interface{}) bool {
+	var groups []interface{}
+	switch steps := steps.(type) {
+	case map[string]interface{}:
+		for _, group := range steps {
+			groups = append(groups, group)
+		}
+	case map[interface{}]interface{}:
+		for _, group := range steps {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return false
+	}
+
+	for _, group := range groups {
+		switch group := group.(type) {
+		case map[string]interface{}:
+			if _, ok := group["latest_version_number"]; ok {
+				return false
+			}
+		case map[interface{}]interface{}:
+			if _, ok := group["latest_version_number"]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortIssues orders the issues by file, document and position, then by their pointers and message.
+func sortIssues(issues []validator.ValidationIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Document != b.Document:
+			return a.Document < b.Document
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Column != b.Column:
+			return a.Column < b.Column
+		case a.InstancePtr != b.InstancePtr:
+			return a.InstancePtr < b.InstancePtr
+		case a.SchemaPtr != b.SchemaPtr:
+			return a.SchemaPtr < b.SchemaPtr
+		}
+		return a.Message < b.Message
+	})
+}
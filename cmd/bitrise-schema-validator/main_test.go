@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+	"github.com/bitrise-io/bitrise-json-schemas/validator"
+)
+
+const validStepYML = `title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
+
+const invalidStepYML = `title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
+
+func Test_detectSchemaKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		pth     string
+		content string
+		want    schemas.Kind
+		wantErr bool
+	}{
+		{name: "bitrise.yml", pth: "bitrise.yml", want: schemas.KindBitriseYML},
+		{name: "prefixed bitrise.yml", pth: "ci/release.bitrise.yaml", want: schemas.KindBitriseYML},
+		{name: "step.yml", pth: "step/step.yml", want: schemas.KindStepYML},
+		{name: "spec.json", pth: "spec.json", want: schemas.KindStepLibSpec},
+		{name: "slim-spec.json", pth: "slim-spec.json", want: schemas.KindStepLibSlimSpec},
+		{name: "bitrise.yml content", pth: "config.yml", content: "format_version: \"13\"\n", want: schemas.KindBitriseYML},
+		{name: "step.yml content", pth: "script.yml", content: validStepYML, want: schemas.KindStepYML},
+		{name: "StepLib spec content", pth: "steplib.json", content: `{"steplib_source": "https://github.com/bitrise-io/bitrise-steplib.git"}`, want: schemas.KindStepLibSpec},
+		{name: "full StepLib spec steps", pth: "steplib.json", content: `{"steplib_source": "https://github.com/bitrise-io/bitrise-steplib.git", "steps": {"script": {"latest_version_number": "1.2.0", "versions": {}}}}`, want: schemas.KindStepLibSpec},
+		{name: "slim StepLib spec steps", pth: "steplib.json", content: `{"steplib_source": "https://github.com/bitrise-io/bitrise-steplib.git", "steps": {"script": {"versions": {}}}}`, want: schemas.KindStepLibSlimSpec},
+		{name: "slim StepLib spec YAML", pth: "steplib.yml", content: "steplib_source: https://github.com/bitrise-io/bitrise-steplib.git\nsteps:\n  script:\n    versions: {}\n", want: schemas.KindStepLibSlimSpec},
+		{name: "unknown content", pth: "config.yml", content: "key: value\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectSchemaKind(tt.pth, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectSchemaKind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("detectSchemaKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortIssues(t *testing.T) {
+	issues := []validator.ValidationIssue{
+		{InstancePtr: "#/workflows/test", Line: 4, Column: 3},
+		{InstancePtr: "#/summary", Line: 2, Column: 1, Message: "b"},
+		{InstancePtr: "#", File: "ci/workflows.yml", Line: 1, Column: 1},
+		{InstancePtr: "#/summary", Line: 2, Column: 1, Message: "a"},
+		{InstancePtr: "#/steps/script", Document: 1},
+		{InstancePtr: "#/steps/git-clone", Document: 1},
+	}
+	sortIssues(issues)
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%s %d %d:%d %s %s", issue.File, issue.Document, issue.Line, issue.Column, issue.InstancePtr, issue.Message))
+	}
+	want := []string{
+		" 0 2:1 #/summary a",
+		" 0 2:1 #/summary b",
+		" 0 4:3 #/workflows/test ",
+		" 1 0:0 #/steps/git-clone ",
+		" 1 0:0 #/steps/script ",
+		"ci/workflows.yml 0 1:1 # ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortIssues() = %q, want %q", got, want)
+	}
+}
+
+func Test_run(t *testing.T) {
+	dir := t.TempDir()
+	validPth := filepath.Join(dir, "valid", "step.yml")
+	invalidPth := filepath.Join(dir, "invalid", "step.yml")
+	for pth, content := range map[string]string{validPth: validStepYML, invalidPth: invalidStepYML} {
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   string
+	}{
+		{name: "valid file", args: []string{validPth}, wantExitCode: exitCodeOK},
+		{
+			name:         "invalid file",
+			args:         []string{"--schema", "step", validPth, invalidPth},
+			wantExitCode: exitCodeValidationFailed,
+			wantOutput:   invalidPth + `:1:1: error: I[#] S[#/required] missing properties: "source_code_url"` + "\n",
+		},
+		{
+			name:         "warning pattern",
+			args:         []string{"--warning-pattern", `S\[#/required\]`, invalidPth},
+			wantExitCode: exitCodeOK,
+			wantOutput:   invalidPth + `:1:1: warning: I[#] S[#/required] missing properties: "source_code_url"` + "\n",
+		},
+		{
+			name:         "human format",
+			args:         []string{"--format", "human", invalidPth},
+			wantExitCode: exitCodeValidationFailed,
+			wantOutput: invalidPth + ":1:1: error: missing required key `source_code_url`\n" +
+				"  hint: title, summary, website, source_code_url and support_url are required\n" +
+				"  docs: https://devcenter.bitrise.io/en/steps-and-workflows/developing-your-own-bitrise-step/developing-a-new-step.html\n",
+		},
+		{name: "invalid warning pattern", args: []string{"--warning-pattern", `S[#/required`, validPth}, wantExitCode: exitCodeError},
+		{name: "unknown format", args: []string{"--format", "xml", validPth}, wantExitCode: exitCodeError},
+		{name: "unknown schema", args: []string{"--schema", "unknown", validPth}, wantExitCode: exitCodeError},
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.yml")}, wantExitCode: exitCodeError},
+		{name: "no files", wantExitCode: exitCodeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(tt.args, &stdout, &stderr); got != tt.wantExitCode {
+				t.Errorf("run() = %d, want %d, stderr: %s", got, tt.wantExitCode, strings.TrimSpace(stderr.String()))
+			}
+			if stdout.String() != tt.wantOutput {
+				t.Errorf("run() output = %q, want %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func Test_run_SARIF(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "step.yml")
+	if err := os.WriteFile(pth, []byte(invalidStepYML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"--format", "sarif", pth}, &stdout, &stderr); got != exitCodeValidationFailed {
+		t.Fatalf("run() = %d, want %d, stderr: %s", got, exitCodeValidationFailed, stderr.String())
+	}
+
+	var log validator.SARIFLog
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF output: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "required" || results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != filepath.ToSlash(pth) {
+		t.Errorf("unexpected SARIF results: %#v", results)
+	}
+}
+
+func Test_run_Fix(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "step.yml")
+	if err := os.WriteFile(pth, []byte(validStepYML+"# Not used anymore\nis_requires_admin_user: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"--fix", pth}, &stdout, &stderr); got != exitCodeOK {
+		t.Fatalf("run() = %d, want %d, stderr: %s", got, exitCodeOK, stderr.String())
+	}
+	if want := pth + ":7:1: fixed: removed the deprecated is_requires_admin_user\n"; stdout.String() != want {
+		t.Errorf("run() output = %q, want %q", stdout.String(), want)
+	}
+
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != validStepYML {
+		t.Errorf("fixed file = %q, want %q", content, validStepYML)
+	}
+}
+
+func Test_run_FixBitriseYML(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	bitriseYML := "format_version: \"13\"\nworkflows:\n  test:\n    steps:\n    - script@1:\n        host_os_tags:\n        - osx-10.10\n"
+	if err := os.WriteFile(pth, []byte(bitriseYML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"--fix", pth}, &stdout, &stderr); got != exitCodeOK {
+		t.Fatalf("run() = %d, want %d, stderr: %s", got, exitCodeOK, stderr.String())
+	}
+	if stdout.String() != "" {
+		t.Errorf("run() output = %q, want none", stdout.String())
+	}
+
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != bitriseYML {
+		t.Errorf("bitrise.yml = %q, want it unchanged", content)
+	}
+}
+
+func Test_run_Includes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"bitrise.yml":      "format_version: \"13\"\ninclude:\n- path: ci/workflows.yml\n",
+		"ci/workflows.yml": "workflows:\n  test:\n    summary: 1\n",
+	}
+	for name, content := range files {
+		pth := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{filepath.Join(dir, "bitrise.yml")}, &stdout, &stderr); got != exitCodeValidationFailed {
+		t.Fatalf("run() = %d, want %d, stderr: %s", got, exitCodeValidationFailed, stderr.String())
+	}
+	want := filepath.Join(dir, "ci", "workflows.yml") + ":3:5: error: I[#/workflows/test/summary] S[#/definitions/WorkflowModel/properties/summary/type] expected string, but got number\n"
+	if stdout.String() != want {
+		t.Errorf("run() output = %q, want %q", stdout.String(), want)
+	}
+}
+
+func Test_run_Graph(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	if err := os.WriteFile(pth, []byte("format_version: \"13\"\nworkflows:\n  test:\n    before_run: [setup]\n  setup: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"--graph", "workflow:test", "--graph-format", "mermaid", pth}, &stdout, &stderr); got != exitCodeOK {
+		t.Fatalf("run() = %d, want %d, stderr: %s", got, exitCodeOK, stderr.String())
+	}
+	want := "flowchart LR\n  n0[\"setup\"]\n  n1[\"test\"]\n  n0 --> n1\n"
+	if stdout.String() != want {
+		t.Errorf("run() output = %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if got := run([]string{"--graph", "pipeline:missing", pth}, &stdout, &stderr); got != exitCodeError {
+		t.Errorf("run() = %d, want %d", got, exitCodeError)
+	}
+	if want := pth + ": pipeline \"missing\" is not defined\n"; stderr.String() != want {
+		t.Errorf("run() stderr = %q, want %q", stderr.String(), want)
+	}
+}
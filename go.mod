@@ -3,6 +3,8 @@ module github.com/bitrise-io/bitrise-json-schemas
 go 1.17
 
 require (
+	github.com/dlclark/regexp2 v1.12.0
 	github.com/santhosh-tekuri/jsonschema/v3 v3.1.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
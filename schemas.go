@@ -2,7 +2,48 @@ package schemas
 
 import (
 	_ "embed"
+	"fmt"
 )
 
+//go:embed bitrise.schema.json
+var BitriseSchema string
+
 //go:embed step.schema.json
 var StepSchema string
+
+//go:embed steplib_spec.schema.json
+var StepLibSpecSchema string
+
+//go:embed steplib_slim_spec.schema.json
+var StepLibSlimSpecSchema string
+
+// Kind identifies one of the embedded schemas.
+type Kind string
+
+const (
+	KindBitriseYML      Kind = "bitrise"
+	KindStepYML         Kind = "step"
+	KindStepLibSpec     Kind = "steplib"
+	KindStepLibSlimSpec Kind = "steplib-slim"
+)
+
+var registry = map[Kind]string{
+	KindBitriseYML:      BitriseSchema,
+	KindStepYML:         StepSchema,
+	KindStepLibSpec:     StepLibSpecSchema,
+	KindStepLibSlimSpec: StepLibSlimSpecSchema,
+}
+
+// Kinds returns every known schema kind.
+func Kinds() []Kind {
+	return []Kind{KindBitriseYML, KindStepYML, KindStepLibSpec, KindStepLibSlimSpec}
+}
+
+// Get returns the embedded schema registered for the given kind.
+func Get(kind Kind) (string, error) {
+	schema, ok := registry[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown schema kind: %s", kind)
+	}
+	return schema, nil
+}
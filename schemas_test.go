@@ -34,6 +34,27 @@ func TestStepSchema(t *testing.T) {
 	}
 }
 
+func TestGet(t *testing.T) {
+	for _, kind := range schemas.Kinds() {
+		schema, err := schemas.Get(kind)
+		if err != nil {
+			t.Errorf("unexpected error for kind %s: %v", kind, err)
+		}
+		if schema == "" {
+			t.Errorf("empty schema for kind %s", kind)
+		}
+	}
+
+	stepSchema, err := schemas.Get(schemas.KindStepYML)
+	if err != nil || stepSchema != schemas.StepSchema {
+		t.Errorf("expected step schema for kind %s", schemas.KindStepYML)
+	}
+
+	if _, err := schemas.Get("unknown"); err == nil {
+		t.Errorf("expected error for unknown kind")
+	}
+}
+
 var tests = []struct {
 	name    string
 	stepYML string
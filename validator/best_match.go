@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v3"
+)
+
+// WithAllAlternatives makes the validator report the issues of every failing oneOf and anyOf branch,
+// instead of only the issues of the best matching branch.
+func WithAllAlternatives() Option {
+	return func(v *JSONSchemaValidator) {
+		v.allAlternatives = true
+	}
+}
+
+// bestMatchingBranch returns the cause of the oneOf or anyOf error which got the furthest in validating the value.
+// A branch matching the type of the value beats the ones that don't, then the branch reaching the deepest
+// part of the value wins, and between those the one with the fewest issues. Ties are resolved by the branch order.
+// Every cause of a failed oneOf or anyOf error belongs to a different branch, in the order of the branches.
+func bestMatchingBranch(err jsonschema.ValidationError) *jsonschema.ValidationError {
+	var best *jsonschema.ValidationError
+	var bestScore branchScore
+	for i, cause := range err.Causes {
+		score := scoreBranch(err.InstancePtr, recursivelyCollectIssues(*cause, nil, false))
+		if i == 0 || score.betterThan(bestScore) {
+			best, bestScore = cause, score
+		}
+	}
+	return best
+}
+
+type branchScore struct {
+	typeMismatch bool
+	depth        int
+	issueCount   int
+}
+
+func (s branchScore) betterThan(other branchScore) bool {
+	if s.typeMismatch != other.typeMismatch {
+		return !s.typeMismatch
+	}
+	if s.depth != other.depth {
+		return s.depth > other.depth
+	}
+	return s.issueCount < other.issueCount
+}
+
+func scoreBranch(instancePtr string, issues []ValidationIssue) branchScore {
+	score := branchScore{issueCount: len(issues)}
+	for _, issue := range issues {
+		if issue.Keyword == "type" && issue.InstancePtr == instancePtr {
+			score.typeMismatch = true
+		}
+		if depth := len(splitPtr(issue.InstancePtr)); depth > score.depth {
+			score.depth = depth
+		}
+	}
+	return score
+}
+
+func isAlternativesKeyword(keyword string) bool {
+	return keyword == "oneOf" || keyword == "anyOf"
+}
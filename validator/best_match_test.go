@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"sort"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_BestMatchingBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		triggerMap    string
+		want          []string
+		wantAllIssues int
+	}{
+		{
+			name: "regex condition with invalid regex type",
+			triggerMap: `trigger_map:
+- push_branch:
+    regex: 1
+  workflow: test
+`,
+			want:          []string{"I[#/trigger_map/0/push_branch/regex] S[#/definitions/TriggerMapItemModelRegexCondition/properties/regex/type] expected string, but got number"},
+			wantAllIssues: 2,
+		},
+		{
+			name: "regex condition with unknown key",
+			triggerMap: `trigger_map:
+- push_branch:
+    rgx: main
+  workflow: test
+`,
+			want:          []string{`I[#/trigger_map/0/push_branch] S[#/definitions/TriggerMapItemModelRegexCondition/additionalProperties] additionalProperties "rgx" not allowed`},
+			wantAllIssues: 2,
+		},
+		{
+			name: "type mismatch in every branch",
+			triggerMap: `trigger_map:
+- push_branch: 1
+  workflow: test
+`,
+			want:          []string{"I[#/trigger_map/0/push_branch] S[#/definitions/TriggerMapItemModelRegexCondition/type] expected object, but got number"},
+			wantAllIssues: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ymlStr := "format_version: \"13\"\n" + tt.triggerMap + "workflows:\n  test: {}\n"
+
+			v, err := NewJSONSchemaValidator(schemas.BitriseSchema)
+			if err != nil {
+				t.Fatalf("Failed to create validator: %s", err)
+			}
+			issues, err := v.ValidateIssues(ymlStr)
+			if err != nil {
+				t.Fatalf("ValidateIssues() error = %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ValidateIssues() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("issue %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+
+			v, err = NewJSONSchemaValidator(schemas.BitriseSchema, WithAllAlternatives())
+			if err != nil {
+				t.Fatalf("Failed to create validator: %s", err)
+			}
+			allIssues, err := v.ValidateIssues(ymlStr)
+			if err != nil {
+				t.Fatalf("ValidateIssues() error = %v", err)
+			}
+			if len(allIssues) != tt.wantAllIssues {
+				t.Errorf("ValidateIssues() with all alternatives = %v, want %d issues", allIssues, tt.wantAllIssues)
+			}
+		})
+	}
+}
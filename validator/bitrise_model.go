@@ -0,0 +1,395 @@
+package validator
+
+import (
+	"sort"
+	"strconv"
+)
+
+// BitriseYML is the part of the bitrise.yml model the semantic checks work on.
+// It is loaded leniently: values of unexpected type are left out, as those are reported by the schema validation.
+// Every element keeps the JSON pointer of its location, so issues can be reported against it.
+type BitriseYML struct {
+	Include     []IncludeItem
+	TriggerMap  []TriggerMapItem
+	Pipelines   map[string]Pipeline
+	Stages      map[string]Stage
+	Workflows   map[string]Workflow
+	StepBundles map[string]StepBundle
+	Containers  map[string]Container
+	Services    map[string]Container
+}
+
+// Reference is a name referring to another element of the bitrise.yml, like a workflow or a stage.
+type Reference struct {
+	Name string
+	Ptr  string
+}
+
+// IncludeItem is an entry of the include list of a modular bitrise.yml.
+// An item without a repository refers to a file of the repository of the including file.
+type IncludeItem struct {
+	Ptr        string
+	Path       string
+	Repository string
+	Branch     string
+	Commit     string
+	Tag        string
+}
+
+type TriggerMapItem struct {
+	Ptr      string
+	Pipeline *Reference
+	Workflow *Reference
+}
+
+type Pipeline struct {
+	Ptr    string
+	Stages []Reference
+	// Workflows are the workflows of a graph pipeline, by their name within the pipeline.
+	Workflows map[string]PipelineWorkflow
+}
+
+// PipelineWorkflow is a workflow of a graph pipeline.
+// Uses is the workflow it runs, if it isn't the workflow with the same name.
+type PipelineWorkflow struct {
+	Ptr       string
+	DependsOn []Reference
+	Uses      *Reference
+}
+
+type Stage struct {
+	Ptr       string
+	Workflows []Reference
+}
+
+type Workflow struct {
+	Ptr       string
+	BeforeRun []Reference
+	AfterRun  []Reference
+	Steps     []Step
+	With      []WithGroup
+}
+
+// WithGroup is a with group of a step list, running its steps in a container along with service containers.
+type WithGroup struct {
+	Ptr       string
+	Container *Reference
+	Services  []Reference
+}
+
+// Container is an entry of the containers or the services of the bitrise.yml.
+type Container struct {
+	Ptr         string
+	Image       *Reference
+	Ports       []Reference
+	Credentials *DockerCredentials
+}
+
+// DockerCredentials are the credentials of the registry of a container image.
+type DockerCredentials struct {
+	Ptr      string
+	Username *Reference
+	Password *Reference
+	Server   *Reference
+}
+
+// Step is an item of a step list: a step, like script@1, or a step bundle reference, like bundle::setup.
+// The steps of a with group are listed in place of the group.
+type Step struct {
+	Ptr string
+	ID  string
+	// Inputs are the inputs set by the step, or overridden by the step bundle reference.
+	Inputs []Reference
+}
+
+type StepBundle struct {
+	Ptr string
+	// Inputs are the inputs the step bundle declares.
+	Inputs []Reference
+	Steps  []Step
+}
+
+// LoadBitriseYML loads the bitrise.yml model from a JSON compatible decoded document.
+func LoadBitriseYML(document interface{}) *BitriseYML {
+	root, _ := document.(map[string]interface{})
+	model := &BitriseYML{
+		Pipelines:   map[string]Pipeline{},
+		Stages:      map[string]Stage{},
+		Workflows:   map[string]Workflow{},
+		StepBundles: map[string]StepBundle{},
+		Containers:  map[string]Container{},
+		Services:    map[string]Container{},
+	}
+
+	for idx, item := range asSlice(root["include"]) {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		include := IncludeItem{Ptr: joinPtr(joinPtr("#", "include"), strconv.Itoa(idx))}
+		include.Path, _ = itemMap["path"].(string)
+		include.Repository, _ = itemMap["repository"].(string)
+		include.Branch, _ = itemMap["branch"].(string)
+		include.Commit, _ = itemMap["commit"].(string)
+		include.Tag, _ = itemMap["tag"].(string)
+		model.Include = append(model.Include, include)
+	}
+
+	for idx, item := range asSlice(root["trigger_map"]) {
+		ptr := joinPtr(joinPtr("#", "trigger_map"), strconv.Itoa(idx))
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		model.TriggerMap = append(model.TriggerMap, TriggerMapItem{
+			Ptr:      ptr,
+			Pipeline: stringReference(itemMap, "pipeline", ptr),
+			Workflow: stringReference(itemMap, "workflow", ptr),
+		})
+	}
+
+	for name, value := range asMap(root["pipelines"]) {
+		ptr := joinPtr(joinPtr("#", "pipelines"), name)
+		pipeline := Pipeline{Ptr: ptr, Workflows: map[string]PipelineWorkflow{}}
+		pipelineMap := asMap(value)
+		for idx, stage := range asSlice(pipelineMap["stages"]) {
+			pipeline.Stages = append(pipeline.Stages, singleKeyReferences(stage, joinPtr(joinPtr(ptr, "stages"), strconv.Itoa(idx)))...)
+		}
+		for workflowName, workflowValue := range asMap(pipelineMap["workflows"]) {
+			workflowPtr := joinPtr(joinPtr(ptr, "workflows"), workflowName)
+			workflowMap := asMap(workflowValue)
+			pipeline.Workflows[workflowName] = PipelineWorkflow{
+				Ptr:       workflowPtr,
+				DependsOn: stringReferences(workflowMap["depends_on"], joinPtr(workflowPtr, "depends_on")),
+				Uses:      stringReference(workflowMap, "uses", workflowPtr),
+			}
+		}
+		model.Pipelines[name] = pipeline
+	}
+
+	for name, value := range asMap(root["stages"]) {
+		ptr := joinPtr(joinPtr("#", "stages"), name)
+		stage := Stage{Ptr: ptr}
+		for idx, workflow := range asSlice(asMap(value)["workflows"]) {
+			stage.Workflows = append(stage.Workflows, singleKeyReferences(workflow, joinPtr(joinPtr(ptr, "workflows"), strconv.Itoa(idx)))...)
+		}
+		model.Stages[name] = stage
+	}
+
+	for name, value := range asMap(root["workflows"]) {
+		ptr := joinPtr(joinPtr("#", "workflows"), name)
+		workflowMap := asMap(value)
+		model.Workflows[name] = Workflow{
+			Ptr:       ptr,
+			BeforeRun: stringReferences(workflowMap["before_run"], joinPtr(ptr, "before_run")),
+			AfterRun:  stringReferences(workflowMap["after_run"], joinPtr(ptr, "after_run")),
+			Steps:     stepListItems(workflowMap["steps"], joinPtr(ptr, "steps")),
+			With:      withGroups(workflowMap["steps"], joinPtr(ptr, "steps")),
+		}
+	}
+
+	for name, value := range asMap(root["step_bundles"]) {
+		ptr := joinPtr(joinPtr("#", "step_bundles"), name)
+		bundleMap := asMap(value)
+		model.StepBundles[name] = StepBundle{
+			Ptr:    ptr,
+			Inputs: envKeyReferences(bundleMap["inputs"], joinPtr(ptr, "inputs")),
+			Steps:  stepListItems(bundleMap["steps"], joinPtr(ptr, "steps")),
+		}
+	}
+
+	for _, kind := range []string{"containers", "services"} {
+		containers := model.Containers
+		if kind == "services" {
+			containers = model.Services
+		}
+		for name, value := range asMap(root[kind]) {
+			ptr := joinPtr(joinPtr("#", kind), name)
+			containerMap := asMap(value)
+			container := Container{
+				Ptr:   ptr,
+				Image: stringReference(containerMap, "image", ptr),
+				Ports: stringReferences(containerMap["ports"], joinPtr(ptr, "ports")),
+			}
+			if credentialsMap, ok := containerMap["credentials"].(map[string]interface{}); ok {
+				credentialsPtr := joinPtr(ptr, "credentials")
+				container.Credentials = &DockerCredentials{
+					Ptr:      credentialsPtr,
+					Username: stringReference(credentialsMap, "username", credentialsPtr),
+					Password: stringReference(credentialsMap, "password", credentialsPtr),
+					Server:   stringReference(credentialsMap, "server", credentialsPtr),
+				}
+			}
+			containers[name] = container
+		}
+	}
+
+	return model
+}
+
+// WorkflowNames returns the names of the defined workflows in alphabetical order.
+func (m BitriseYML) WorkflowNames() []string {
+	names := make([]string, 0, len(m.Workflows))
+	for name := range m.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PipelineNames returns the names of the defined pipelines in alphabetical order.
+func (m BitriseYML) PipelineNames() []string {
+	names := make([]string, 0, len(m.Pipelines))
+	for name := range m.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WorkflowNames returns the names of the workflows of the graph pipeline in alphabetical order.
+func (p Pipeline) WorkflowNames() []string {
+	names := make([]string, 0, len(p.Workflows))
+	for name := range p.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StepBundleNames returns the names of the defined step bundles in alphabetical order.
+func (m BitriseYML) StepBundleNames() []string {
+	names := make([]string, 0, len(m.StepBundles))
+	for name := range m.StepBundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ContainerNames returns the names of the defined containers in alphabetical order.
+func (m BitriseYML) ContainerNames() []string {
+	names := make([]string, 0, len(m.Containers))
+	for name := range m.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServiceNames returns the names of the defined service containers in alphabetical order.
+func (m BitriseYML) ServiceNames() []string {
+	names := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StageNames returns the names of the defined stages in alphabetical order.
+func (m BitriseYML) StageNames() []string {
+	names := make([]string, 0, len(m.Stages))
+	for name := range m.Stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func asSlice(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
+
+func stringReference(m map[string]interface{}, key, ptr string) *Reference {
+	name, ok := m[key].(string)
+	if !ok {
+		return nil
+	}
+	return &Reference{Name: name, Ptr: joinPtr(ptr, key)}
+}
+
+func stringReferences(value interface{}, ptr string) []Reference {
+	var references []Reference
+	for idx, item := range asSlice(value) {
+		if name, ok := item.(string); ok {
+			references = append(references, Reference{Name: name, Ptr: joinPtr(ptr, strconv.Itoa(idx))})
+		}
+	}
+	return references
+}
+
+// stepListItems returns the items of a step list, the steps of with groups in place of the group.
+func stepListItems(value interface{}, ptr string) []Step {
+	var steps []Step
+	for idx, item := range asSlice(value) {
+		itemPtr := joinPtr(ptr, strconv.Itoa(idx))
+		itemMap := asMap(item)
+		if len(itemMap) != 1 {
+			continue
+		}
+		for id, step := range itemMap {
+			if id == "with" {
+				steps = append(steps, stepListItems(asMap(step)["steps"], joinPtr(joinPtr(itemPtr, id), "steps"))...)
+				continue
+			}
+			steps = append(steps, Step{Ptr: itemPtr, ID: id, Inputs: envKeyReferences(asMap(step)["inputs"], joinPtr(joinPtr(itemPtr, id), "inputs"))})
+		}
+	}
+	return steps
+}
+
+// withGroups returns the with groups of a step list.
+func withGroups(value interface{}, ptr string) []WithGroup {
+	var groups []WithGroup
+	for idx, item := range asSlice(value) {
+		group, ok := asMap(item)["with"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groupPtr := joinPtr(joinPtr(ptr, strconv.Itoa(idx)), "with")
+		groups = append(groups, WithGroup{
+			Ptr:       groupPtr,
+			Container: stringReference(group, "container", groupPtr),
+			Services:  stringReferences(group["services"], joinPtr(groupPtr, "services")),
+		})
+	}
+	return groups
+}
+
+// envKeyReferences returns the keys of an env list, like `- INPUT: value`, leaving out the opts of the items.
+func envKeyReferences(value interface{}, ptr string) []Reference {
+	var references []Reference
+	for idx, item := range asSlice(value) {
+		for _, key := range sortedKeys(asMap(item)) {
+			if key != "opts" {
+				references = append(references, Reference{Name: key, Ptr: joinPtr(joinPtr(ptr, strconv.Itoa(idx)), key)})
+			}
+		}
+	}
+	return references
+}
+
+// singleKeyReferences returns the keys of a list item like `- stage_name: {...}`.
+func singleKeyReferences(value interface{}, ptr string) []Reference {
+	var references []Reference
+	for _, name := range sortedKeys(asMap(value)) {
+		references = append(references, Reference{Name: name, Ptr: joinPtr(ptr, name)})
+	}
+	return references
+}
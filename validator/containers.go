@@ -0,0 +1,189 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	KeywordUndefinedContainer = "undefinedContainer"
+	KeywordUndefinedService   = "undefinedService"
+	KeywordInvalidImage       = "invalidImage"
+	KeywordInvalidPortMapping = "invalidPortMapping"
+	KeywordInvalidCredentials = "invalidCredentials"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordUndefinedContainer: "Reference to an undefined container",
+	KeywordUndefinedService:   "Reference to an undefined service",
+	KeywordInvalidImage:       "Invalid container image reference",
+	KeywordInvalidPortMapping: "Invalid container port mapping",
+	KeywordInvalidCredentials: "Invalid container registry credentials",
+})
+
+// CheckContainers reports the with groups of workflows referring to undefined containers or services,
+// and the containers and services with an invalid image reference, port mapping or credentials.
+//
+// Ports have to be host:container port mappings, optionally followed by the /tcp or /udp protocol.
+// Credentials need a non-empty username and password. Their server, if given, has to be the registry of the image,
+// as they are used to log in to that server. Without a server they are used for the registry of the image.
+// Values referring to environment variables, like $IMAGE, are not checked, as they are only known at build time.
+func CheckContainers(document interface{}) []ValidationIssue {
+	model := LoadBitriseYML(document)
+
+	var issues []ValidationIssue
+	for _, name := range model.WorkflowNames() {
+		for _, group := range model.Workflows[name].With {
+			if group.Container != nil {
+				if _, ok := model.Containers[group.Container.Name]; !ok {
+					issues = append(issues, undefinedReferenceIssue(*group.Container, "container", KeywordUndefinedContainer))
+				}
+			}
+			for _, service := range group.Services {
+				if _, ok := model.Services[service.Name]; !ok {
+					issues = append(issues, undefinedReferenceIssue(service, "service", KeywordUndefinedService))
+				}
+			}
+		}
+	}
+
+	for _, name := range model.ContainerNames() {
+		issues = append(issues, containerIssues(model.Containers[name])...)
+	}
+	for _, name := range model.ServiceNames() {
+		issues = append(issues, containerIssues(model.Services[name])...)
+	}
+
+	return issues
+}
+
+func containerIssues(container Container) []ValidationIssue {
+	var issues []ValidationIssue
+
+	var image *ImageReference
+	if container.Image != nil && !hasEnvVarReference(container.Image.Name) {
+		parsed, err := ParseImageReference(container.Image.Name)
+		if err != nil {
+			issues = append(issues, ValidationIssue{
+				InstancePtr: container.Image.Ptr,
+				SchemaPtr:   semanticSchemaPtr(KeywordInvalidImage),
+				Message:     err.Error(),
+				Keyword:     KeywordInvalidImage,
+			})
+		} else {
+			image = &parsed
+		}
+	}
+
+	hostPorts := map[int]bool{}
+	for _, port := range container.Ports {
+		if hasEnvVarReference(port.Name) {
+			continue
+		}
+		hostPort, err := parsePortMapping(port.Name)
+		if err == nil && hostPorts[hostPort] {
+			err = fmt.Errorf("host port %d is already mapped", hostPort)
+		}
+		if err != nil {
+			issues = append(issues, ValidationIssue{
+				InstancePtr: port.Ptr,
+				SchemaPtr:   semanticSchemaPtr(KeywordInvalidPortMapping),
+				Message:     fmt.Sprintf("invalid port mapping %q: %s", port.Name, err),
+				Keyword:     KeywordInvalidPortMapping,
+			})
+			continue
+		}
+		hostPorts[hostPort] = true
+	}
+
+	if credentials := container.Credentials; credentials != nil {
+		for _, field := range []*Reference{credentials.Username, credentials.Password} {
+			if field != nil && strings.TrimSpace(field.Name) == "" {
+				issues = append(issues, ValidationIssue{
+					InstancePtr: field.Ptr,
+					SchemaPtr:   semanticSchemaPtr(KeywordInvalidCredentials),
+					Message:     fmt.Sprintf("credentials %s is empty", field.Ptr[strings.LastIndex(field.Ptr, "/")+1:]),
+					Keyword:     KeywordInvalidCredentials,
+				})
+			}
+		}
+		issues = append(issues, credentialsServerIssues(*credentials, image)...)
+	}
+
+	return issues
+}
+
+// credentialsServerIssues reports a credentials server which is not a registry host, or not the registry of the image.
+// Credentials without a server are used for the registry of the image, so they are not reported.
+func credentialsServerIssues(credentials DockerCredentials, image *ImageReference) []ValidationIssue {
+	if credentials.Server == nil || hasEnvVarReference(credentials.Server.Name) {
+		return nil
+	}
+
+	server, ptr := registryHost(credentials.Server.Name), credentials.Server.Ptr
+	if !imageDomainRe.MatchString(server) {
+		return []ValidationIssue{{
+			InstancePtr: ptr,
+			SchemaPtr:   semanticSchemaPtr(KeywordInvalidCredentials),
+			Message:     fmt.Sprintf("credentials server %q is not a registry host", credentials.Server.Name),
+			Keyword:     KeywordInvalidCredentials,
+		}}
+	}
+	if image == nil || normalizeRegistry(server) == normalizeRegistry(image.Domain) {
+		return nil
+	}
+	return []ValidationIssue{{
+		InstancePtr: ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordInvalidCredentials),
+		Message:     fmt.Sprintf("credentials are used for registry %s, but the image is pulled from %s", server, image.Domain),
+		Keyword:     KeywordInvalidCredentials,
+	}}
+}
+
+// parsePortMapping parses a host:container[/protocol] port mapping and returns the host port.
+func parsePortMapping(mapping string) (int, error) {
+	ports := mapping
+	if idx := strings.Index(mapping, "/"); idx != -1 {
+		ports = mapping[:idx]
+		if protocol := mapping[idx+1:]; protocol != "tcp" && protocol != "udp" {
+			return 0, fmt.Errorf("unknown protocol %q, expected tcp or udp", protocol)
+		}
+	}
+
+	parts := strings.Split(ports, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("expected host:container ports")
+	}
+	for _, part := range parts {
+		if port, err := strconv.Atoi(part); err != nil || port < 1 || port > 65535 {
+			return 0, fmt.Errorf("%q is not a port number between 1 and 65535", part)
+		}
+	}
+	hostPort, _ := strconv.Atoi(parts[0])
+	return hostPort, nil
+}
+
+// registryHost returns the host of a registry server, which can be given as a URL, like https://ghcr.io/v2/.
+func registryHost(server string) string {
+	if idx := strings.Index(server, "://"); idx != -1 {
+		server = server[idx+3:]
+	}
+	if idx := strings.Index(server, "/"); idx != -1 {
+		server = server[:idx]
+	}
+	return server
+}
+
+// normalizeRegistry maps the aliases of Docker Hub to the default image domain.
+func normalizeRegistry(host string) string {
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return DefaultImageDomain
+	}
+	return host
+}
+
+func hasEnvVarReference(value string) bool {
+	return strings.Contains(value, "$")
+}
@@ -0,0 +1,105 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+const bitriseYMLWithContainers = `format_version: "13"
+containers:
+  golang:
+    image: golang:1.21
+    credentials:
+      username: $DOCKER_USER
+      password: $DOCKER_PASSWORD
+  private:
+    image: ghcr.io/org/image:1.0
+    credentials:
+      username: ""
+      password: $GHCR_TOKEN
+  invalid:
+    image: Org/Image
+    credentials:
+      username: user
+      password: $PASSWORD
+      server: https://ghcr.io/v2/
+  dynamic:
+    image: $IMAGE
+services:
+  postgres:
+    image: postgres:16
+    ports:
+    - 5432:5432
+    - 5432:5433
+    - "6379"
+    - 8080:80/http
+    - 0:80
+workflows:
+  test:
+    steps:
+    - with:
+        container: golnag
+        services:
+        - postgres
+        - redis
+        steps:
+        - script@1: {}
+    - with:
+        container: golang
+        steps:
+        - script@1: {}
+`
+
+func TestCheckContainers(t *testing.T) {
+	issues := CheckContainers(decodeTestYAML(t, bitriseYMLWithContainers))
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/workflows/test/steps/0/with/container", SchemaPtr: semanticSchemaPtr(KeywordUndefinedContainer), Message: `container "golnag" is not defined`, Keyword: KeywordUndefinedContainer},
+		{InstancePtr: "#/workflows/test/steps/0/with/services/1", SchemaPtr: semanticSchemaPtr(KeywordUndefinedService), Message: `service "redis" is not defined`, Keyword: KeywordUndefinedService},
+		{InstancePtr: "#/containers/invalid/image", SchemaPtr: semanticSchemaPtr(KeywordInvalidImage), Message: `invalid image reference "Org/Image": repository name must be lowercase`, Keyword: KeywordInvalidImage},
+		{InstancePtr: "#/containers/private/credentials/username", SchemaPtr: semanticSchemaPtr(KeywordInvalidCredentials), Message: "credentials username is empty", Keyword: KeywordInvalidCredentials},
+		{InstancePtr: "#/services/postgres/ports/1", SchemaPtr: semanticSchemaPtr(KeywordInvalidPortMapping), Message: `invalid port mapping "5432:5433": host port 5432 is already mapped`, Keyword: KeywordInvalidPortMapping},
+		{InstancePtr: "#/services/postgres/ports/2", SchemaPtr: semanticSchemaPtr(KeywordInvalidPortMapping), Message: `invalid port mapping "6379": expected host:container ports`, Keyword: KeywordInvalidPortMapping},
+		{InstancePtr: "#/services/postgres/ports/3", SchemaPtr: semanticSchemaPtr(KeywordInvalidPortMapping), Message: `invalid port mapping "8080:80/http": unknown protocol "http", expected tcp or udp`, Keyword: KeywordInvalidPortMapping},
+		{InstancePtr: "#/services/postgres/ports/4", SchemaPtr: semanticSchemaPtr(KeywordInvalidPortMapping), Message: `invalid port mapping "0:80": "0" is not a port number between 1 and 65535`, Keyword: KeywordInvalidPortMapping},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckContainers() =\n%#v\nwant\n%#v", issues, want)
+	}
+}
+
+func Test_credentialsServerIssues(t *testing.T) {
+	ghcr := &ImageReference{Domain: "ghcr.io", Path: "org/image"}
+	hub := &ImageReference{Domain: "docker.io", Path: "library/golang"}
+	server := func(name string) DockerCredentials {
+		return DockerCredentials{Ptr: "#/c", Server: &Reference{Name: name, Ptr: "#/c/server"}}
+	}
+
+	tests := []struct {
+		name        string
+		credentials DockerCredentials
+		image       *ImageReference
+		want        string
+	}{
+		{name: "matching server", credentials: server("ghcr.io"), image: ghcr},
+		{name: "matching server URL", credentials: server("https://ghcr.io/v2/"), image: ghcr},
+		{name: "docker hub alias", credentials: server("https://index.docker.io/v1/"), image: hub},
+		{name: "docker hub default", credentials: DockerCredentials{Ptr: "#/c"}, image: hub},
+		{name: "registry inferred from the image", credentials: DockerCredentials{Ptr: "#/c"}, image: ghcr},
+		{name: "env var server", credentials: server("$REGISTRY"), image: ghcr},
+		{name: "other registry", credentials: server("quay.io"), image: ghcr, want: "credentials are used for registry quay.io, but the image is pulled from ghcr.io"},
+		{name: "invalid server", credentials: server("https://"), image: ghcr, want: `credentials server "https://" is not a registry host`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := credentialsServerIssues(tt.credentials, tt.image)
+			var got string
+			if len(issues) > 0 {
+				got = issues[0].Message
+			}
+			if got != tt.want {
+				t.Errorf("credentialsServerIssues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagram is a directed graph of bitrise.yml elements, which can be rendered as Graphviz DOT or Mermaid text.
+// The IDs of the nodes and clusters are unique identifiers made of ASCII letters, digits and underscores.
+type Diagram struct {
+	Name     string
+	Clusters []DiagramCluster
+	// Nodes are the nodes outside of the clusters.
+	Nodes []DiagramNode
+	// Edges connect nodes or clusters, in the order they are rendered.
+	Edges []DiagramEdge
+}
+
+// DiagramCluster is a group of nodes, like the workflows of a stage.
+type DiagramCluster struct {
+	ID    string
+	Label string
+	Nodes []DiagramNode
+}
+
+type DiagramNode struct {
+	ID    string
+	Label string
+}
+
+type DiagramEdge struct {
+	From string
+	To   string
+}
+
+// PipelineDiagram returns the diagram of the pipeline of the bitrise.yml.
+// The stages of a staged pipeline are clusters of their workflows, connected in the order they run.
+// The workflows of a graph pipeline are connected to the workflows depending on them.
+func PipelineDiagram(document interface{}, name string) (*Diagram, error) {
+	model := LoadBitriseYML(document)
+	pipeline, ok := model.Pipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("pipeline %q is not defined", name)
+	}
+
+	diagram := &Diagram{Name: name}
+	if len(pipeline.Workflows) > 0 {
+		ids := map[string]string{}
+		for idx, workflowName := range pipeline.WorkflowNames() {
+			ids[workflowName] = fmt.Sprintf("n%d", idx)
+			label := workflowName
+			if uses := pipeline.Workflows[workflowName].Uses; uses != nil {
+				label = fmt.Sprintf("%s (uses %s)", workflowName, uses.Name)
+			}
+			diagram.Nodes = append(diagram.Nodes, DiagramNode{ID: ids[workflowName], Label: label})
+		}
+		for _, workflowName := range pipeline.WorkflowNames() {
+			for _, dependency := range pipeline.Workflows[workflowName].DependsOn {
+				if from, ok := ids[dependency.Name]; ok && dependency.Name != workflowName {
+					diagram.Edges = append(diagram.Edges, DiagramEdge{From: from, To: ids[workflowName]})
+				}
+			}
+		}
+		return diagram, nil
+	}
+
+	for idx, stage := range pipeline.Stages {
+		cluster := DiagramCluster{ID: fmt.Sprintf("c%d", idx), Label: stage.Name}
+		for workflowIdx, workflow := range model.Stages[stage.Name].Workflows {
+			cluster.Nodes = append(cluster.Nodes, DiagramNode{ID: fmt.Sprintf("n%d_%d", idx, workflowIdx), Label: workflow.Name})
+		}
+		diagram.Clusters = append(diagram.Clusters, cluster)
+		if idx > 0 {
+			diagram.Edges = append(diagram.Edges, DiagramEdge{From: diagram.Clusters[idx-1].ID, To: cluster.ID})
+		}
+	}
+	return diagram, nil
+}
+
+// WorkflowChainDiagram returns the diagram of the workflows run by the workflow of the bitrise.yml,
+// in the order they run: the expanded before_run workflows, the workflow itself and the expanded after_run workflows.
+// A workflow running itself through its before_run or after_run workflows is not expanded again.
+func WorkflowChainDiagram(document interface{}, name string) (*Diagram, error) {
+	model := LoadBitriseYML(document)
+	if _, ok := model.Workflows[name]; !ok {
+		return nil, fmt.Errorf("workflow %q is not defined", name)
+	}
+
+	diagram := &Diagram{Name: name}
+	walkWorkflowChain(model, name, func(workflowName string, _ Workflow) {
+		node := DiagramNode{ID: fmt.Sprintf("n%d", len(diagram.Nodes)), Label: workflowName}
+		if len(diagram.Nodes) > 0 {
+			diagram.Edges = append(diagram.Edges, DiagramEdge{From: diagram.Nodes[len(diagram.Nodes)-1].ID, To: node.ID})
+		}
+		diagram.Nodes = append(diagram.Nodes, node)
+	}, nil)
+
+	return diagram, nil
+}
+
+// DOT renders the diagram as a Graphviz DOT digraph. Edges between clusters are drawn between their first nodes,
+// clipped to the cluster borders, as DOT can't connect clusters directly.
+func (d Diagram) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(d.Name))
+	b.WriteString("  rankdir=LR;\n")
+	if len(d.Clusters) > 0 {
+		b.WriteString("  compound=true;\n")
+	}
+
+	firstNodes := map[string]string{}
+	for _, cluster := range d.Clusters {
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n", cluster.ID)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(cluster.Label))
+		for _, node := range cluster.Nodes {
+			fmt.Fprintf(&b, "    %s [label=%s];\n", node.ID, dotQuote(node.Label))
+		}
+		b.WriteString("  }\n")
+		if len(cluster.Nodes) > 0 {
+			firstNodes[cluster.ID] = cluster.Nodes[0].ID
+		}
+	}
+	for _, node := range d.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", node.ID, dotQuote(node.Label))
+	}
+
+	for _, edge := range d.Edges {
+		from, to := edge.From, edge.To
+		var attributes []string
+		if first, ok := firstNodes[from]; ok {
+			from = first
+			attributes = append(attributes, "ltail=cluster_"+edge.From)
+		}
+		if first, ok := firstNodes[to]; ok {
+			to = first
+			attributes = append(attributes, "lhead=cluster_"+edge.To)
+		}
+		if d.isCluster(from) || d.isCluster(to) {
+			// One of the clusters is empty, there is no node to draw the edge from or to.
+			continue
+		}
+		if len(attributes) > 0 {
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", from, to, strings.Join(attributes, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", from, to)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the diagram as a Mermaid flowchart.
+func (d Diagram) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, cluster := range d.Clusters {
+		fmt.Fprintf(&b, "  subgraph %s [%s]\n", cluster.ID, mermaidQuote(cluster.Label))
+		for _, node := range cluster.Nodes {
+			fmt.Fprintf(&b, "    %s[%s]\n", node.ID, mermaidQuote(node.Label))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, node := range d.Nodes {
+		fmt.Fprintf(&b, "  %s[%s]\n", node.ID, mermaidQuote(node.Label))
+	}
+	for _, edge := range d.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", edge.From, edge.To)
+	}
+	return b.String()
+}
+
+func (d Diagram) isCluster(id string) bool {
+	for _, cluster := range d.Clusters {
+		if cluster.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// dotQuote returns the DOT quoted string of the text, non-ASCII characters are kept as they are.
+func dotQuote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+	text = strings.ReplaceAll(text, "\n", `\n`)
+	return `"` + text + `"`
+}
+
+// mermaidQuote returns the Mermaid quoted string of the text, quotes are escaped as HTML entities.
+func mermaidQuote(text string) string {
+	text = strings.ReplaceAll(text, `"`, "#quot;")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return `"` + text + `"`
+}
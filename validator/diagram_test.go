@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"testing"
+)
+
+const bitriseYMLForDiagrams = `format_version: "13"
+pipelines:
+  staged:
+    stages:
+    - build: {}
+    - "deploy \"prod\"": {}
+  graph:
+    workflows:
+      build: {}
+      test:
+        depends_on: [build]
+      deploy:
+        depends_on: [build, test]
+        uses: release
+stages:
+  build:
+    workflows:
+    - build: {}
+    - test: {}
+  "deploy \"prod\"":
+    workflows:
+    - release: {}
+workflows:
+  build:
+    before_run: [setup]
+    after_run: [report]
+  setup:
+    before_run: [build]
+  test: {}
+  release: {}
+  report: {}
+`
+
+func TestPipelineDiagram_Staged(t *testing.T) {
+	diagram, err := PipelineDiagram(decodeTestYAML(t, bitriseYMLForDiagrams), "staged")
+	if err != nil {
+		t.Fatalf("PipelineDiagram() error = %v", err)
+	}
+
+	wantDOT := `digraph "staged" {
+  rankdir=LR;
+  compound=true;
+  subgraph cluster_c0 {
+    label="build";
+    n0_0 [label="build"];
+    n0_1 [label="test"];
+  }
+  subgraph cluster_c1 {
+    label="deploy \"prod\"";
+    n1_0 [label="release"];
+  }
+  n0_0 -> n1_0 [ltail=cluster_c0, lhead=cluster_c1];
+}
+`
+	if got := diagram.DOT(); got != wantDOT {
+		t.Errorf("DOT() =\n%s\nwant\n%s", got, wantDOT)
+	}
+
+	wantMermaid := `flowchart LR
+  subgraph c0 ["build"]
+    n0_0["build"]
+    n0_1["test"]
+  end
+  subgraph c1 ["deploy #quot;prod#quot;"]
+    n1_0["release"]
+  end
+  c0 --> c1
+`
+	if got := diagram.Mermaid(); got != wantMermaid {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", got, wantMermaid)
+	}
+}
+
+func TestPipelineDiagram_Graph(t *testing.T) {
+	diagram, err := PipelineDiagram(decodeTestYAML(t, bitriseYMLForDiagrams), "graph")
+	if err != nil {
+		t.Fatalf("PipelineDiagram() error = %v", err)
+	}
+
+	wantDOT := `digraph "graph" {
+  rankdir=LR;
+  n0 [label="build"];
+  n1 [label="deploy (uses release)"];
+  n2 [label="test"];
+  n0 -> n1;
+  n2 -> n1;
+  n0 -> n2;
+}
+`
+	if got := diagram.DOT(); got != wantDOT {
+		t.Errorf("DOT() =\n%s\nwant\n%s", got, wantDOT)
+	}
+
+	wantMermaid := `flowchart LR
+  n0["build"]
+  n1["deploy (uses release)"]
+  n2["test"]
+  n0 --> n1
+  n2 --> n1
+  n0 --> n2
+`
+	if got := diagram.Mermaid(); got != wantMermaid {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", got, wantMermaid)
+	}
+}
+
+func TestWorkflowChainDiagram(t *testing.T) {
+	diagram, err := WorkflowChainDiagram(decodeTestYAML(t, bitriseYMLForDiagrams), "build")
+	if err != nil {
+		t.Fatalf("WorkflowChainDiagram() error = %v", err)
+	}
+
+	wantMermaid := `flowchart LR
+  n0["setup"]
+  n1["build"]
+  n2["report"]
+  n0 --> n1
+  n1 --> n2
+`
+	if got := diagram.Mermaid(); got != wantMermaid {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", got, wantMermaid)
+	}
+}
+
+func TestPipelineDiagram_Undefined(t *testing.T) {
+	if _, err := PipelineDiagram(decodeTestYAML(t, bitriseYMLForDiagrams), "missing"); err == nil {
+		t.Errorf("PipelineDiagram() error = nil, want an undefined pipeline error")
+	}
+	if _, err := WorkflowChainDiagram(decodeTestYAML(t, bitriseYMLForDiagrams), "missing"); err == nil {
+		t.Errorf("WorkflowChainDiagram() error = nil, want an undefined workflow error")
+	}
+}
@@ -0,0 +1,417 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// YAMLStream is a parsed YAML stream. Besides the plain values the schema validation works on,
+// it keeps the node tree of every document with its comments, key order and anchors.
+//
+// Edits made through the stream are applied both to the node trees and to the source text,
+// so the edited stream keeps the source byte-for-byte, except for the edited parts.
+// Edits which can't be mapped onto the source, like the ones within flow collections,
+// make the stream re-encode its node trees instead.
+type YAMLStream struct {
+	source     []byte
+	lineStarts []int
+	Documents  []*YAMLDocument
+
+	edits    []sourceEdit
+	reencode bool
+}
+
+// YAMLDocument is a single document of a YAMLStream.
+type YAMLDocument struct {
+	// Root is the root node of the document, it is nil for an empty document.
+	Root *yamlv3.Node
+	// Value is the JSON compatible plain value of the document, decoded with YAML 1.1 semantics.
+	// It reflects the document as it was parsed, edits don't change it.
+	Value interface{}
+
+	stream *YAMLStream
+	node   *yamlv3.Node
+}
+
+type sourceEdit struct {
+	start, end int
+	text       string
+}
+
+// ParseYAML parses every document of the YAML stream. An empty stream is parsed as a single empty document.
+func ParseYAML(ymlStr string) (*YAMLStream, error) {
+	source := []byte(ymlStr)
+	documents, err := decodeYAMLDocuments(source)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &YAMLStream{
+		source:     source,
+		lineStarts: lineStarts(source),
+		Documents:  documents,
+	}
+	nodes, _ := parseDocumentNodes(source)
+	for idx, document := range documents {
+		document.stream = stream
+		if idx < len(nodes) {
+			document.node = nodes[idx]
+			document.Root = documentRoot(nodes[idx])
+		}
+	}
+
+	return stream, nil
+}
+
+func (s *YAMLStream) roots() []*yamlv3.Node {
+	roots := make([]*yamlv3.Node, 0, len(s.Documents))
+	for _, document := range s.Documents {
+		roots = append(roots, document.Root)
+	}
+	return roots
+}
+
+// Bytes returns the YAML stream with the edits applied.
+func (s *YAMLStream) Bytes() ([]byte, error) {
+	if s.reencode {
+		var buf bytes.Buffer
+		encoder := yamlv3.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		for _, document := range s.Documents {
+			if document.node == nil {
+				continue
+			}
+			if err := encoder.Encode(document.node); err != nil {
+				return nil, err
+			}
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	edits := append([]sourceEdit{}, s.edits...)
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var buf bytes.Buffer
+	pos := 0
+	for _, edit := range edits {
+		buf.Write(s.source[pos:edit.start])
+		buf.WriteString(edit.text)
+		pos = edit.end
+	}
+	buf.Write(s.source[pos:])
+	return buf.Bytes(), nil
+}
+
+// SetScalar sets the tag and the value of the scalar node.
+// Quoted scalars keep their quoting style, the other ones are rendered as plain scalars if possible.
+func (d *YAMLDocument) SetScalar(node *yamlv3.Node, tag, value string) {
+	s := d.stream
+	wasScalar := node.Kind == yamlv3.ScalarNode && node.Anchor == ""
+	start, startOK := s.offset(node.Line, node.Column)
+	end, endOK := s.scalarEnd(node)
+
+	node.Kind = yamlv3.ScalarNode
+	node.Tag = tag
+	node.Value = value
+	node.Style &= yamlv3.DoubleQuotedStyle | yamlv3.SingleQuotedStyle
+	node.Content = nil
+
+	if !wasScalar || !startOK || !endOK {
+		s.reencode = true
+		return
+	}
+	text, err := renderScalar(node)
+	if err != nil {
+		s.reencode = true
+		return
+	}
+	s.addEdit(sourceEdit{start: start, end: end, text: text})
+}
+
+// RemoveMappingEntry removes the entry with the given key node from the mapping node, along with its head comment.
+func (d *YAMLDocument) RemoveMappingEntry(mapping, key *yamlv3.Node) {
+	s := d.stream
+	idx := -1
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i] == key {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return
+	}
+	value := mapping.Content[idx+1]
+	var next *yamlv3.Node
+	if idx+2 < len(mapping.Content) {
+		next = mapping.Content[idx+2]
+	}
+	mapping.Content = append(mapping.Content[:idx], mapping.Content[idx+2:]...)
+
+	edit, ok := s.mappingEntryEdit(mapping, key, value, next)
+	if !ok {
+		s.reencode = true
+		return
+	}
+	s.addEdit(edit)
+}
+
+func (s *YAMLStream) mappingEntryEdit(mapping, key, value, next *yamlv3.Node) (sourceEdit, bool) {
+	if mapping.Style&yamlv3.FlowStyle != 0 {
+		return sourceEdit{}, false
+	}
+	keyStart, ok := s.offset(key.Line, key.Column)
+	if !ok {
+		return sourceEdit{}, false
+	}
+	valueEnd, ok := s.nodeEnd(value)
+	if !ok {
+		return sourceEdit{}, false
+	}
+
+	lineStart := s.lineStarts[key.Line-1]
+	if strings.TrimSpace(string(s.source[lineStart:keyStart])) == "" {
+		// The key starts its line: remove the whole lines of the entry, including its head comment.
+		start := lineStart
+		if key.HeadComment != "" {
+			for line := key.Line - 1; line >= 1; line-- {
+				text := strings.TrimSpace(string(s.lineBytes(line)))
+				if !strings.HasPrefix(text, "#") {
+					break
+				}
+				start = s.lineStarts[line-1]
+			}
+		}
+		return sourceEdit{start: start, end: s.lineEnd(valueEnd, true)}, true
+	}
+
+	// The key follows a sequence item indicator, like - key: value.
+	// The next entry takes its place, or the emptied mapping is replaced with {}.
+	if next == nil {
+		return sourceEdit{start: keyStart, end: valueEnd, text: "{}"}, true
+	}
+	if next.HeadComment != "" {
+		return sourceEdit{}, false
+	}
+	nextStart, ok := s.offset(next.Line, next.Column)
+	if !ok {
+		return sourceEdit{}, false
+	}
+	return sourceEdit{start: keyStart, end: nextStart}, true
+}
+
+func (s *YAMLStream) addEdit(edit sourceEdit) {
+	for _, other := range s.edits {
+		if edit.start < other.end && other.start < edit.end || edit.start == other.start {
+			s.reencode = true
+			return
+		}
+	}
+	s.edits = append(s.edits, edit)
+}
+
+// offset returns the byte offset of the 1-based line and column (counted in characters) in the source.
+func (s *YAMLStream) offset(line, column int) (int, bool) {
+	if line < 1 || line > len(s.lineStarts) || column < 1 {
+		return 0, false
+	}
+	offset := s.lineStarts[line-1]
+	for i := 1; i < column; i++ {
+		if offset >= len(s.source) || s.source[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(s.source[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// lineBytes returns the 1-based line without its line break.
+func (s *YAMLStream) lineBytes(line int) []byte {
+	start := s.lineStarts[line-1]
+	end := len(s.source)
+	if line < len(s.lineStarts) {
+		end = s.lineStarts[line]
+	}
+	return bytes.TrimRight(s.source[start:end], "\r\n")
+}
+
+// lineEnd returns the offset of the line break ending the line of the offset,
+// or the offset after the line break if includeBreak is set.
+func (s *YAMLStream) lineEnd(offset int, includeBreak bool) int {
+	idx := bytes.IndexByte(s.source[offset:], '\n')
+	if idx == -1 {
+		return len(s.source)
+	}
+	if includeBreak {
+		return offset + idx + 1
+	}
+	return offset + idx
+}
+
+// lineIndent returns the indentation of the line containing the offset.
+func (s *YAMLStream) lineIndent(offset int) int {
+	start := bytes.LastIndexByte(s.source[:offset], '\n') + 1
+	indent := 0
+	for start+indent < len(s.source) && s.source[start+indent] == ' ' {
+		indent++
+	}
+	return indent
+}
+
+// nodeEnd returns the offset right after the last character of the node in the source.
+func (s *YAMLStream) nodeEnd(node *yamlv3.Node) (int, bool) {
+	start, ok := s.offset(node.Line, node.Column)
+	if !ok {
+		return 0, false
+	}
+
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		return s.scalarEnd(node)
+	case yamlv3.AliasNode:
+		return start + len("*") + len(node.Value), true
+	case yamlv3.MappingNode, yamlv3.SequenceNode:
+		if node.Style&yamlv3.FlowStyle != 0 {
+			return s.flowCollectionEnd(start)
+		}
+		end := start
+		for _, child := range node.Content {
+			childEnd, ok := s.nodeEnd(child)
+			if !ok {
+				return 0, false
+			}
+			if childEnd > end {
+				end = childEnd
+			}
+		}
+		return end, true
+	}
+	return 0, false
+}
+
+// scalarEnd returns the offset right after the last character of the scalar node in the source.
+func (s *YAMLStream) scalarEnd(node *yamlv3.Node) (int, bool) {
+	start, ok := s.offset(node.Line, node.Column)
+	if !ok || node.Kind != yamlv3.ScalarNode {
+		return 0, false
+	}
+
+	switch {
+	case node.Style&yamlv3.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(s.source); i++ {
+			switch s.source[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case node.Style&yamlv3.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(s.source); i++ {
+			if s.source[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s.source) && s.source[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1, true
+		}
+		return 0, false
+	case node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0 && bytes.HasPrefix(s.source[start:], []byte(node.Value)):
+		return start + len(node.Value), true
+	}
+
+	// Block scalars and multi-line plain scalars span the lines indented more than their first line.
+	indent := s.lineIndent(start)
+	end := bytes.TrimRight(s.source[:s.lineEnd(start, false)], " \t\r")
+	endOffset := len(end)
+	for offset := s.lineEnd(start, true); offset < len(s.source); offset = s.lineEnd(offset, true) {
+		line := s.source[offset:s.lineEnd(offset, false)]
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if s.lineIndent(offset) <= indent {
+			break
+		}
+		endOffset = offset + len(bytes.TrimRight(line, " \t\r"))
+	}
+	return endOffset, true
+}
+
+// flowCollectionEnd returns the offset right after the bracket closing the flow collection starting at the offset.
+func (s *YAMLStream) flowCollectionEnd(start int) (int, bool) {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s.source); i++ {
+		c := s.source[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// renderScalar renders the value of the scalar node as a single line, without the node's comments.
+func renderScalar(node *yamlv3.Node) (string, error) {
+	scalar := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: node.Tag, Value: node.Value, Style: node.Style}
+	out, err := yamlv3.Marshal(scalar)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSuffix(string(out), "\n")
+	if strings.Contains(text, "\n") {
+		scalar.Style = yamlv3.DoubleQuotedStyle
+		if out, err = yamlv3.Marshal(scalar); err != nil {
+			return "", err
+		}
+		text = strings.TrimSuffix(string(out), "\n")
+	}
+	if strings.Contains(text, "\n") {
+		return "", fmt.Errorf("scalar can't be rendered as a single line")
+	}
+	return text, nil
+}
+
+func lineStarts(source []byte) []int {
+	starts := []int{0}
+	for i, c := range source {
+		if c == '\n' && i+1 < len(source) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+func documentRoot(document *yamlv3.Node) *yamlv3.Node {
+	if document != nil && document.Kind == yamlv3.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package validator
+
+import (
+	"testing"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestYAMLStream_RoundTrip(t *testing.T) {
+	ymlStr := `# Build config
+format_version: "13" # comment
+app:
+  envs:
+  - &project PROJECT: app.xcodeproj
+  - SCHEME: "App"    # kept as is
+workflows:
+  test: {steps: [script@1]}
+---
+title:   'Second'
+`
+	stream, err := ParseYAML(ymlStr)
+	if err != nil {
+		t.Fatalf("ParseYAML() error = %v", err)
+	}
+	if len(stream.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(stream.Documents))
+	}
+	if stream.Documents[1].Value.(map[string]interface{})["title"] != "Second" {
+		t.Errorf("unexpected value: %v", stream.Documents[1].Value)
+	}
+
+	got, err := stream.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	if string(got) != ymlStr {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, ymlStr)
+	}
+}
+
+func TestYAMLDocument_SetScalar(t *testing.T) {
+	tests := []struct {
+		name   string
+		ymlStr string
+		ptr    string
+		tag    string
+		value  string
+		want   string
+	}{
+		{
+			name:   "plain scalar with comment",
+			ymlStr: "a: 1\nb: false # keep\nc: 3\n",
+			ptr:    "#/b",
+			tag:    "!!bool",
+			value:  "true",
+			want:   "a: 1\nb: true # keep\nc: 3\n",
+		},
+		{
+			name:   "double quoted scalar keeps its style",
+			ymlStr: "title: \"Old \\\"title\\\"\"\nsummary: x\n",
+			ptr:    "#/title",
+			tag:    "!!str",
+			value:  "New",
+			want:   "title: \"New\"\nsummary: x\n",
+		},
+		{
+			name:   "single quoted scalar keeps its style",
+			ymlStr: "title: 'It''s old'\n",
+			ptr:    "#/title",
+			tag:    "!!str",
+			value:  "It's new",
+			want:   "title: 'It''s new'\n",
+		},
+		{
+			name:   "literal block scalar",
+			ymlStr: "summary: |\n  First line.\n  Second line.\n\nwebsite: x\n",
+			ptr:    "#/summary",
+			tag:    "!!str",
+			value:  "First line.",
+			want:   "summary: First line.\n\nwebsite: x\n",
+		},
+		{
+			name:   "multi-line plain scalar",
+			ymlStr: "summary: First\n  second\nwebsite: x\n",
+			ptr:    "#/summary",
+			tag:    "!!str",
+			value:  "First",
+			want:   "summary: First\nwebsite: x\n",
+		},
+		{
+			name:   "string which needs quoting",
+			ymlStr: "- a\n- b\n",
+			ptr:    "#/1",
+			tag:    "!!str",
+			value:  "true",
+			want:   "- a\n- \"true\"\n",
+		},
+		{
+			name:   "multi-line value",
+			ymlStr: "summary: x\n",
+			ptr:    "#/summary",
+			tag:    "!!str",
+			value:  "a\nb",
+			want:   "summary: \"a\\nb\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := ParseYAML(tt.ymlStr)
+			if err != nil {
+				t.Fatalf("ParseYAML() error = %v", err)
+			}
+			document := stream.Documents[0]
+			document.SetScalar(nodeAt(t, document.Root, tt.ptr), tt.tag, tt.value)
+
+			got, err := stream.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLDocument_RemoveMappingEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		ymlStr string
+		ptr    string
+		key    string
+		want   string
+	}{
+		{
+			name:   "entry with head comment and nested value",
+			ymlStr: "title: x\n# Deprecated\nhost_os_tags:\n- osx-10.10\n- osx-10.11\nsummary: y # keep\n",
+			key:    "host_os_tags",
+			want:   "title: x\nsummary: y # keep\n",
+		},
+		{
+			name:   "last entry",
+			ymlStr: "title: x\ndeps:\n  brew:\n  - name: go\n",
+			key:    "deps",
+			want:   "title: x\n",
+		},
+		{
+			name:   "first entry of a sequence item",
+			ymlStr: "- a: 1\n  b: 2\n- c: 3\n",
+			ptr:    "#/0",
+			key:    "a",
+			want:   "- b: 2\n- c: 3\n",
+		},
+		{
+			name:   "only entry of a sequence item",
+			ymlStr: "- a: 1\n- c: 3\n",
+			ptr:    "#/0",
+			key:    "a",
+			want:   "- {}\n- c: 3\n",
+		},
+		{
+			name:   "flow mapping is re-encoded",
+			ymlStr: "step: {a: 1, b: 2}\n",
+			ptr:    "#/step",
+			key:    "a",
+			want:   "step: {b: 2}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := ParseYAML(tt.ymlStr)
+			if err != nil {
+				t.Fatalf("ParseYAML() error = %v", err)
+			}
+			document := stream.Documents[0]
+			mapping := nodeAt(t, document.Root, tt.ptr)
+			key, _ := mappingEntry(mapping, tt.key)
+			document.RemoveMappingEntry(mapping, key)
+
+			got, err := stream.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func nodeAt(t *testing.T, root *yamlv3.Node, ptr string) *yamlv3.Node {
+	node := root
+	for _, token := range splitPtr(ptr) {
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			_, node = mappingEntry(node, token)
+		case yamlv3.SequenceNode:
+			idx := 0
+			for _, c := range token {
+				idx = idx*10 + int(c-'0')
+			}
+			node = node.Content[idx]
+		}
+		if node == nil {
+			t.Fatalf("no node at %s", ptr)
+		}
+	}
+	return node
+}
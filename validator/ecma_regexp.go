@@ -0,0 +1,294 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlclark/regexp2"
+	"github.com/santhosh-tekuri/jsonschema/v3"
+)
+
+// The schema compiler evaluates pattern and patternProperties with Go's RE2 based regexp package,
+// which doesn't support ECMA-262 features, like the lookaheads used in bitrise.schema.json.
+// Before compiling a schema these keywords are moved under the below extension keywords,
+// and are evaluated by the ecmaRegexExtension with ECMA-262 semantics.
+// Their issues are reported with the original pattern, patternProperties and additionalProperties schema pointers.
+const (
+	ecmaRegexExtensionName = "ecmaRegex"
+
+	ecmaPatternKeyword              = "x-ecma-pattern"
+	ecmaPatternPropertiesKeyword    = "x-ecma-patternProperties"
+	ecmaAdditionalPropertiesKeyword = "x-ecma-additionalProperties"
+)
+
+// ecmaRegexMatchTimeout bounds the time a single pattern match can take, as backtracking patterns can explode.
+const ecmaRegexMatchTimeout = time.Second
+
+func compileECMARegex(pattern string) (*regexp2.Regexp, error) {
+	re, err := regexp2.Compile(pattern, regexp2.ECMAScript)
+	if err != nil {
+		return nil, err
+	}
+	re.MatchTimeout = ecmaRegexMatchTimeout
+	return re, nil
+}
+
+// rewriteSchemaRegexKeywords moves the regex based keywords of the schema under the ECMA-262 extension keywords.
+// Every pattern is compiled up front, an invalid one is returned as a SchemaCompileError naming its schema pointer.
+// The schema compiler doesn't look for $id values under the extension keywords, so a $ref can't refer to
+// a patternProperties or additionalProperties subschema by its plain-name $id; schemaRefs reports these $refs.
+func rewriteSchemaRegexKeywords(schema interface{}, ptr string) error {
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if pattern, ok := m["pattern"].(string); ok {
+		if _, err := compileECMARegex(pattern); err != nil {
+			return &SchemaCompileError{SchemaPtr: joinPtr(ptr, "pattern"), Reason: fmt.Sprintf("invalid pattern %q: %s", pattern, err), Err: err}
+		}
+		m[ecmaPatternKeyword] = pattern
+		delete(m, "pattern")
+	}
+
+	if patternProperties, ok := m["patternProperties"].(map[string]interface{}); ok {
+		for _, pattern := range sortedKeys(patternProperties) {
+			if _, err := compileECMARegex(pattern); err != nil {
+				return &SchemaCompileError{SchemaPtr: joinPtr(ptr, "patternProperties"), Reason: fmt.Sprintf("invalid pattern %q: %s", pattern, err), Err: err}
+			}
+		}
+		m[ecmaPatternPropertiesKeyword] = patternProperties
+		delete(m, "patternProperties")
+		if additionalProperties, ok := m["additionalProperties"]; ok {
+			m[ecmaAdditionalPropertiesKeyword] = additionalProperties
+			delete(m, "additionalProperties")
+		}
+	}
+
+	for _, keyword := range sortedKeys(m) {
+		value := m[keyword]
+		switch keyword {
+		case "additionalItems", "additionalProperties", "contains", "not", "if", "then", "else", "propertyNames",
+			ecmaAdditionalPropertiesKeyword:
+			if err := rewriteSchemaRegexKeywords(value, joinPtr(ptr, originalKeyword(keyword))); err != nil {
+				return err
+			}
+		case "properties", "definitions", "$defs", "dependencies", ecmaPatternPropertiesKeyword:
+			subschemas := asMap(value)
+			for _, name := range sortedKeys(subschemas) {
+				if err := rewriteSchemaRegexKeywords(subschemas[name], joinPtr(joinPtr(ptr, originalKeyword(keyword)), name)); err != nil {
+					return err
+				}
+			}
+		case "items", "allOf", "anyOf", "oneOf":
+			if subschemas, ok := value.([]interface{}); ok {
+				for idx, subschema := range subschemas {
+					if err := rewriteSchemaRegexKeywords(subschema, joinPtr(joinPtr(ptr, keyword), strconv.Itoa(idx))); err != nil {
+						return err
+					}
+				}
+			} else if err := rewriteSchemaRegexKeywords(value, joinPtr(ptr, keyword)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func originalKeyword(keyword string) string {
+	switch keyword {
+	case ecmaPatternKeyword:
+		return "pattern"
+	case ecmaPatternPropertiesKeyword:
+		return "patternProperties"
+	case ecmaAdditionalPropertiesKeyword:
+		return "additionalProperties"
+	}
+	return keyword
+}
+
+type ecmaPatternProperty struct {
+	pattern string
+	re      *regexp2.Regexp
+	schema  *jsonschema.Schema
+}
+
+type ecmaRegexSchema struct {
+	pattern   string
+	patternRe *regexp2.Regexp
+
+	properties        map[string]bool
+	patternProperties []ecmaPatternProperty
+	// additionalProperties is nil if not set, false if additional properties are not allowed,
+	// or the *jsonschema.Schema the additional properties have to be valid against.
+	additionalProperties interface{}
+}
+
+func ecmaRegexExtension() jsonschema.Extension {
+	return jsonschema.Extension{
+		Compile:  compileECMARegexSchema,
+		Validate: validateECMARegexSchema,
+	}
+}
+
+func compileECMARegexSchema(ctx jsonschema.CompilerContext, m map[string]interface{}) (interface{}, error) {
+	pattern, hasPattern := m[ecmaPatternKeyword].(string)
+	patternProperties, hasPatternProperties := m[ecmaPatternPropertiesKeyword].(map[string]interface{})
+	if !hasPattern && !hasPatternProperties {
+		return nil, nil
+	}
+
+	s := &ecmaRegexSchema{}
+	if hasPattern {
+		re, err := compileECMARegex(pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.pattern, s.patternRe = pattern, re
+	}
+
+	if hasPatternProperties {
+		s.properties = map[string]bool{}
+		for name := range asMap(m["properties"]) {
+			s.properties[name] = true
+		}
+
+		for _, pattern := range sortedKeys(patternProperties) {
+			re, err := compileECMARegex(pattern)
+			if err != nil {
+				return nil, err
+			}
+			schema, err := ctx.Compile(patternProperties[pattern])
+			if err != nil {
+				return nil, err
+			}
+			s.patternProperties = append(s.patternProperties, ecmaPatternProperty{pattern: pattern, re: re, schema: schema})
+		}
+
+		switch additionalProperties := m[ecmaAdditionalPropertiesKeyword].(type) {
+		case bool:
+			if !additionalProperties {
+				s.additionalProperties = false
+			}
+		case map[string]interface{}:
+			schema, err := ctx.Compile(additionalProperties)
+			if err != nil {
+				return nil, err
+			}
+			s.additionalProperties = schema
+		}
+	}
+
+	return s, nil
+}
+
+func validateECMARegexSchema(ctx jsonschema.ValidationContext, compiled interface{}, v interface{}) error {
+	s := compiled.(*ecmaRegexSchema)
+	var errs []error
+
+	switch v := v.(type) {
+	case string:
+		if s.patternRe != nil {
+			matched, err := s.patternRe.MatchString(v)
+			if err != nil {
+				errs = append(errs, ctx.Error("pattern", "failed to match pattern %q: %s", s.pattern, err))
+			} else if !matched {
+				errs = append(errs, ctx.Error("pattern", "does not match pattern %q", s.pattern))
+			}
+		}
+	case map[string]interface{}:
+		if s.patternProperties == nil {
+			break
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var additional []string
+		for _, name := range names {
+			matchedAny := s.properties[name]
+			for _, property := range s.patternProperties {
+				matched, err := property.re.MatchString(name)
+				if err != nil {
+					errs = append(errs, ctx.Error(joinPtr("patternProperties", property.pattern), "failed to match pattern %q: %s", property.pattern, err))
+					continue
+				}
+				if !matched {
+					continue
+				}
+				matchedAny = true
+				if err := ctx.Validate(property.schema, v[name]); err != nil {
+					errs = append(errs, addErrorContext(escapePtrToken(name), joinPtr("patternProperties", property.pattern), err))
+				}
+			}
+			if !matchedAny {
+				additional = append(additional, name)
+			}
+		}
+
+		switch additionalProperties := s.additionalProperties.(type) {
+		case bool:
+			if len(additional) > 0 {
+				quoted := make([]string, 0, len(additional))
+				for _, name := range additional {
+					quoted = append(quoted, strconv.Quote(name))
+				}
+				errs = append(errs, ctx.Error("additionalProperties", "additionalProperties %s not allowed", strings.Join(quoted, ", ")))
+			}
+		case *jsonschema.Schema:
+			for _, name := range additional {
+				if err := ctx.Validate(additionalProperties, v[name]); err != nil {
+					errs = append(errs, addErrorContext(escapePtrToken(name), "additionalProperties", err))
+				}
+			}
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		parent := ctx.Error("", "validation failed")
+		return parent.Group(parent, errs...)
+	}
+}
+
+// addErrorContext prefixes the relative pointers of the validation error tree, like the schema validator does
+// for errors of nested schemas. Errors already finished in a referenced schema keep their schema pointer.
+func addErrorContext(instancePtr, schemaPtr string, err error) error {
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	ve.InstancePtr = joinRelativePtr(instancePtr, ve.InstancePtr)
+	if ve.SchemaURL == "" {
+		ve.SchemaPtr = joinRelativePtr(schemaPtr, ve.SchemaPtr)
+	}
+	for _, cause := range ve.Causes {
+		_ = addErrorContext(instancePtr, schemaPtr, cause)
+	}
+	return ve
+}
+
+func joinRelativePtr(ptr1, ptr2 string) string {
+	if ptr1 == "" {
+		return ptr2
+	}
+	if ptr2 == "" {
+		return ptr1
+	}
+	return ptr1 + "/" + ptr2
+}
+
+func escapePtrToken(token string) string {
+	return strings.TrimPrefix(joinPtr("", token), "/")
+}
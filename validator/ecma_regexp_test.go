@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_ECMARegex(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "version": {
+      "type": "string",
+      "pattern": "^(?!0\\.)\\d+\\.\\d+$"
+    },
+    "repeated": {
+      "type": "string",
+      "pattern": "^(\\w+)-\\1$"
+    }
+  },
+  "patternProperties": {
+    "^(?!x-)c[a-z]*$": {
+      "type": "integer"
+    }
+  },
+  "additionalProperties": false
+}`
+	v, err := NewJSONSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateIssues(`
+version: "1.2"
+repeated: abc-abc
+count: 1
+`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("ValidateIssues() got unexpected issues = %v", issues)
+	}
+
+	issues, err = v.ValidateIssues(`
+version: "0.2"
+repeated: abc-abd
+count: one
+x-custom: 1
+`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	sort.Strings(got)
+	want := []string{
+		`I[#/count] S[#/patternProperties/%5E%28%3F%21x-%29c%5Ba-z%5D%2A$/type] expected integer, but got string`,
+		`I[#/repeated] S[#/properties/repeated/pattern] does not match pattern "^(\\w+)-\\1$"`,
+		`I[#/version] S[#/properties/version/pattern] does not match pattern "^(?!0\\.)\\d+\\.\\d+$"`,
+		`I[#] S[#/additionalProperties] additionalProperties "x-custom" not allowed`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateIssues() got = %v, want %v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNewJSONSchemaValidator_InvalidPattern(t *testing.T) {
+	_, err := NewJSONSchemaValidator(`{"properties": {"name": {"type": "string", "pattern": "^(unclosed$"}}}`)
+	if err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+	if !strings.Contains(err.Error(), "#/properties/name/pattern") {
+		t.Errorf("expected error to name the schema pointer, got: %s", err)
+	}
+}
+
+func TestJSONSchemaValidator_BitriseSchema(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.BitriseSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateIssues(`
+format_version: "13"
+workflows:
+  test:
+    steps:
+    - script@1:
+        inputs:
+        - content: echo "hello"
+    - bundle::setup: {}
+    - with:
+        steps:
+        - script@1:
+            title: In container
+    - script@1:
+        with: not-a-step-property
+`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	want := []ValidationIssue{{
+		InstancePtr: "#/workflows/test/steps/3/script@1",
+		SchemaPtr:   "#/definitions/StepModel/additionalProperties",
+		Message:     `additionalProperties "with" not allowed`,
+		Keyword:     "additionalProperties",
+		Severity:    SeverityError,
+		Line:        14,
+		Column:      7,
+	}}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateIssues() got = %#v, want %#v", issues, want)
+	}
+}
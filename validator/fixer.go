@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"fmt"
+)
+
+// Fix is a mechanical fix of a YAML document, applied to the document's node tree.
+type Fix struct {
+	// Name identifies the fix in the reported changes, like expand-sensitive-inputs.
+	Name string
+	// Apply fixes the document through its edit methods and returns the changes it made.
+	Apply func(document *YAMLDocument) []FixChange
+}
+
+// FixChange is a single change made by a fix.
+type FixChange struct {
+	// Fix is the name of the fix which made the change.
+	Fix string
+	// InstancePtr is the JSON pointer of the changed value, like #/inputs/0/opts/is_expand.
+	InstancePtr string
+	// Description tells what was changed.
+	Description string
+	// Line and Column locate the changed key or value in the original YAML source, both are 1-based.
+	Line   int
+	Column int
+	// Document is the 0-based index of the document in a multi-document YAML stream.
+	Document int
+}
+
+func (c FixChange) String() string {
+	return fmt.Sprintf("I[%s] %s", c.InstancePtr, c.Description)
+}
+
+// FixResult is the outcome of fixing a YAML document.
+type FixResult struct {
+	// Fixed is the fixed YAML document. It is the original document if no changes were made.
+	Fixed string
+	// Changes are the changes made by the fixes, in the order they were applied.
+	Changes []FixChange
+	// Issues are the issues of the fixed document, the ones the fixes couldn't resolve.
+	Issues []ValidationIssue
+}
+
+// Fixer applies fixes to YAML documents and validates the fixed documents.
+// The fixed documents keep their source, including comments and key order, except for the fixed parts.
+type Fixer struct {
+	validator *JSONSchemaValidator
+	fixes     []Fix
+}
+
+// NewFixer returns a Fixer applying the given fixes in order, and validating the fixed documents with the validator.
+func NewFixer(v *JSONSchemaValidator, fixes ...Fix) *Fixer {
+	return &Fixer{
+		validator: v,
+		fixes:     fixes,
+	}
+}
+
+// Fix applies the fixes to every document of the YAML stream and validates the fixed stream.
+func (f Fixer) Fix(ymlStr string, warningPatterns ...string) (*FixResult, error) {
+	stream, err := ParseYAML(ymlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FixResult{Fixed: ymlStr}
+	for documentIdx, document := range stream.Documents {
+		if document.Root == nil {
+			continue
+		}
+		for _, fix := range f.fixes {
+			for _, change := range fix.Apply(document) {
+				change.Fix = fix.Name
+				change.Document = documentIdx
+				result.Changes = append(result.Changes, change)
+			}
+		}
+	}
+
+	if len(result.Changes) > 0 {
+		fixed, err := stream.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the fixed document: %w", err)
+		}
+		result.Fixed = string(fixed)
+	}
+
+	issues, err := f.validator.ValidateDocuments(result.Fixed, warningPatterns...)
+	if err != nil {
+		return nil, err
+	}
+	result.Issues = issues
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestFixer_StepYML(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+	fixer := NewFixer(v, StepYMLFixes()...)
+
+	result, err := fixer.Fix(`# Script step
+title: Script
+summary: |
+  Run any custom script you want.
+  Supports bash and other languages.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+host_os_tags:
+- osx-10.10
+is_requires_admin_user: false
+inputs:
+# The access token
+- token: $TOKEN
+  opts:
+    title: Token
+    summary: Access token
+    is_sensitive: true
+    is_expand: false
+`)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+
+	wantFixed := `# Script step
+title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+inputs:
+# The access token
+- token: $TOKEN
+  opts:
+    title: Token
+    summary: Access token
+    is_sensitive: true
+    is_expand: true
+`
+	if result.Fixed != wantFixed {
+		t.Errorf("Fixed =\n%s\nwant\n%s", result.Fixed, wantFixed)
+	}
+
+	wantChanges := []string{
+		"I[#/inputs/0/opts/is_expand] set is_expand to true, as the value is sensitive",
+		"I[#/host_os_tags] removed the deprecated host_os_tags",
+		"I[#/is_requires_admin_user] removed the deprecated is_requires_admin_user",
+		"I[#/summary] trimmed the summary to a single line",
+	}
+	if len(result.Changes) != len(wantChanges) {
+		t.Fatalf("Changes = %v, want %v", result.Changes, wantChanges)
+	}
+	for i, change := range result.Changes {
+		if change.String() != wantChanges[i] {
+			t.Errorf("change %d = %s, want %s", i, change, wantChanges[i])
+		}
+	}
+	if result.Changes[0].Fix != FixExpandSensitiveInputs || result.Changes[0].Line != 19 || result.Changes[0].Column != 5 {
+		t.Errorf("unexpected change: %#v", result.Changes[0])
+	}
+
+	if len(result.Issues) != 0 {
+		t.Errorf("Issues = %v, want none", result.Issues)
+	}
+}
+
+func TestFixer_NoChanges(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	ymlStr := "title:   Script\n" + validStepYML[len("title: Script\n"):] + "summary: x\n"
+	result, err := NewFixer(v, StepYMLFixes()...).Fix(ymlStr)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if result.Fixed != ymlStr || len(result.Changes) != 0 {
+		t.Errorf("Fix() = %#v, want the unchanged document", result)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Keyword != KeywordDuplicateKey {
+		t.Errorf("Issues = %v, want the duplicate summary", result.Issues)
+	}
+}
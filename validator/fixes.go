@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	FixExpandSensitiveInputs = "expand-sensitive-inputs"
+	FixDropDeprecatedKeys    = "drop-deprecated-keys"
+	FixSingleLineSummary     = "single-line-summary"
+)
+
+// deprecatedStepKeys are the step keys which are not used anymore.
+var deprecatedStepKeys = []string{"dependencies", "host_os_tags", "is_requires_admin_user"}
+
+// StepYMLFixes returns the fixes of step.yml files.
+func StepYMLFixes() []Fix {
+	return []Fix{
+		{Name: FixExpandSensitiveInputs, Apply: expandSensitiveInputs},
+		{Name: FixDropDeprecatedKeys, Apply: dropDeprecatedKeys},
+		{Name: FixSingleLineSummary, Apply: singleLineSummary},
+	}
+}
+
+// expandSensitiveInputs sets is_expand to true in the options of the sensitive inputs and outputs,
+// as sensitive values are always expanded.
+func expandSensitiveInputs(document *YAMLDocument) []FixChange {
+	var changes []FixChange
+	for _, listKey := range []string{"inputs", "outputs"} {
+		_, list := mappingEntry(document.Root, listKey)
+		if list == nil || list.Kind != yamlv3.SequenceNode {
+			continue
+		}
+		for idx, item := range list.Content {
+			_, opts := mappingEntry(item, "opts")
+			if opts == nil || opts.Kind != yamlv3.MappingNode {
+				continue
+			}
+			_, isSensitive := mappingEntry(opts, "is_sensitive")
+			isExpandKey, isExpand := mappingEntry(opts, "is_expand")
+			if !isTrue(isSensitive) || isExpand == nil || isTrue(isExpand) {
+				continue
+			}
+
+			changes = append(changes, FixChange{
+				InstancePtr: joinPtr(joinPtr(joinPtr(joinPtr("#", listKey), strconv.Itoa(idx)), "opts"), "is_expand"),
+				Description: "set is_expand to true, as the value is sensitive",
+				Line:        isExpandKey.Line,
+				Column:      isExpandKey.Column,
+			})
+			document.SetScalar(isExpand, "!!bool", "true")
+		}
+	}
+	return changes
+}
+
+// dropDeprecatedKeys removes the deprecated keys of the step.
+func dropDeprecatedKeys(document *YAMLDocument) []FixChange {
+	step := document.Root
+	if step.Kind != yamlv3.MappingNode {
+		return nil
+	}
+
+	var changes []FixChange
+	for _, key := range deprecatedStepKeys {
+		for i := len(step.Content) - 2; i >= 0; i -= 2 {
+			keyNode := step.Content[i]
+			if keyNode.Value != key {
+				continue
+			}
+
+			changes = append(changes, FixChange{
+				InstancePtr: joinPtr("#", key),
+				Description: fmt.Sprintf("removed the deprecated %s", key),
+				Line:        keyNode.Line,
+				Column:      keyNode.Column,
+			})
+			document.RemoveMappingEntry(step, keyNode)
+		}
+	}
+	return changes
+}
+
+// singleLineSummary trims the summary of the step to its first non-empty line.
+func singleLineSummary(document *YAMLDocument) []FixChange {
+	summaryKey, summary := mappingEntry(document.Root, "summary")
+	if summary == nil || summary.Kind != yamlv3.ScalarNode || summary.Tag != "!!str" {
+		return nil
+	}
+
+	var firstLine string
+	for _, line := range strings.Split(summary.Value, "\n") {
+		if firstLine = strings.TrimSpace(line); firstLine != "" {
+			break
+		}
+	}
+	if firstLine == "" || firstLine == summary.Value {
+		return nil
+	}
+
+	change := FixChange{
+		InstancePtr: joinPtr("#", "summary"),
+		Description: "trimmed the summary to a single line",
+		Line:        summaryKey.Line,
+		Column:      summaryKey.Column,
+	}
+	document.SetScalar(summary, "!!str", firstLine)
+	return []FixChange{change}
+}
+
+func isTrue(node *yamlv3.Node) bool {
+	var value bool
+	return node != nil && node.Kind == yamlv3.ScalarNode && node.Decode(&value) == nil && value
+}
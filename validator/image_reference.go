@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultImageDomain is the registry of the image references without a domain.
+const DefaultImageDomain = "docker.io"
+
+// The grammar of image references, as Docker parses them.
+var (
+	imageDomainRe        = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	imagePathComponentRe = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageTagRe           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRe        = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:[0-9a-fA-F]{32,}$`)
+)
+
+// maxImageNameLength is the maximum length of the domain and path of an image reference.
+const maxImageNameLength = 255
+
+// ImageReference is a parsed container image reference, like ghcr.io/org/image:1.0.
+type ImageReference struct {
+	// Domain is the registry of the image, DefaultImageDomain if the reference doesn't name one.
+	Domain string
+	// Path is the repository of the image within the registry, like library/golang for golang.
+	Path   string
+	Tag    string
+	Digest string
+}
+
+func (r ImageReference) String() string {
+	str := r.Domain + "/" + r.Path
+	if r.Tag != "" {
+		str += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		str += "@" + r.Digest
+	}
+	return str
+}
+
+// ParseImageReference parses an image reference of the [domain[:port]/]path[:tag][@digest] form.
+// The first path component is the domain if it contains a dot or a port, or if it is localhost.
+// Official images of the default registry get the library/ prefix, like Docker does.
+func ParseImageReference(reference string) (ImageReference, error) {
+	if reference == "" {
+		return ImageReference{}, fmt.Errorf("invalid image reference: empty reference")
+	}
+	invalid := func(format string, args ...interface{}) (ImageReference, error) {
+		return ImageReference{}, fmt.Errorf("invalid image reference %q: %s", reference, fmt.Sprintf(format, args...))
+	}
+
+	var parsed ImageReference
+	name := reference
+	if idx := strings.Index(name, "@"); idx != -1 {
+		name, parsed.Digest = name[:idx], name[idx+1:]
+		if !imageDigestRe.MatchString(parsed.Digest) {
+			return invalid("invalid digest %q", parsed.Digest)
+		}
+	}
+	if idx := strings.LastIndex(name, ":"); idx != -1 && idx > strings.LastIndex(name, "/") {
+		name, parsed.Tag = name[:idx], name[idx+1:]
+		if !imageTagRe.MatchString(parsed.Tag) {
+			return invalid("invalid tag %q", parsed.Tag)
+		}
+	}
+	if len(name) > maxImageNameLength {
+		return invalid("name is longer than %d characters", maxImageNameLength)
+	}
+
+	parsed.Domain, parsed.Path = DefaultImageDomain, name
+	if idx := strings.Index(name, "/"); idx != -1 {
+		first := name[:idx]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			if !imageDomainRe.MatchString(first) {
+				return invalid("invalid registry %q", first)
+			}
+			parsed.Domain, parsed.Path = first, name[idx+1:]
+		}
+	}
+	for _, component := range strings.Split(parsed.Path, "/") {
+		if !imagePathComponentRe.MatchString(component) {
+			if strings.ToLower(component) != component {
+				return invalid("repository name must be lowercase")
+			}
+			return invalid("invalid repository name component %q", component)
+		}
+	}
+	if parsed.Domain == DefaultImageDomain && !strings.Contains(parsed.Path, "/") {
+		parsed.Path = "library/" + parsed.Path
+	}
+
+	return parsed, nil
+}
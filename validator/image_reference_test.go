@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      ImageReference
+		wantErr   string
+	}{
+		{reference: "golang", want: ImageReference{Domain: "docker.io", Path: "library/golang"}},
+		{reference: "golang:1.21-alpine", want: ImageReference{Domain: "docker.io", Path: "library/golang", Tag: "1.21-alpine"}},
+		{reference: "bitriseio/android-ndk:latest", want: ImageReference{Domain: "docker.io", Path: "bitriseio/android-ndk", Tag: "latest"}},
+		{reference: "ghcr.io/org/team/image:1.0", want: ImageReference{Domain: "ghcr.io", Path: "org/team/image", Tag: "1.0"}},
+		{reference: "localhost:5000/image", want: ImageReference{Domain: "localhost:5000", Path: "image"}},
+		{reference: "localhost/image", want: ImageReference{Domain: "localhost", Path: "image"}},
+		{
+			reference: "redis@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			want:      ImageReference{Domain: "docker.io", Path: "library/redis", Digest: "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+		},
+		{reference: "", wantErr: "invalid image reference: empty reference"},
+		{reference: "Golang:1.21", wantErr: `invalid image reference "Golang:1.21": repository name must be lowercase`},
+		{reference: "golang:1.21:alpine", wantErr: `invalid image reference "golang:1.21:alpine": invalid repository name component "golang:1.21"`},
+		{reference: "golang:", wantErr: `invalid image reference "golang:": invalid tag ""`},
+		{reference: "org//image", wantErr: `invalid image reference "org//image": invalid repository name component ""`},
+		{reference: "-ghcr.io/image", wantErr: `invalid image reference "-ghcr.io/image": invalid registry "-ghcr.io"`},
+		{reference: "redis@sha256:abc", wantErr: `invalid image reference "redis@sha256:abc": invalid digest "sha256:abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			got, err := ParseImageReference(tt.reference)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseImageReference() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseImageReference() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseImageReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
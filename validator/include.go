@@ -0,0 +1,341 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// KeywordUnresolvedInclude is the keyword of issues reporting include items which couldn't be resolved or parsed.
+const KeywordUnresolvedInclude = "unresolvedInclude"
+
+var _ = describeRules(map[string]string{
+	KeywordUnresolvedInclude: "Include which can't be resolved",
+})
+
+// IncludeResolver reads the files include items of a modular bitrise.yml refer to.
+type IncludeResolver interface {
+	// Resolve returns the name and the content of the file the include item refers to.
+	// The name identifies the file in the issues, like its path.
+	Resolve(item IncludeItem) (name string, content []byte, err error)
+}
+
+// Name returns the name of the included file: the path for files of the local repository,
+// and the repository, the git reference and the path otherwise, like https://github.com/org/repo.git@main:ci/steps.yml.
+func (i IncludeItem) Name() string {
+	if i.Repository == "" {
+		return i.Path
+	}
+	if ref := i.ref(); ref != "" {
+		return fmt.Sprintf("%s@%s:%s", i.Repository, ref, i.Path)
+	}
+	return fmt.Sprintf("%s:%s", i.Repository, i.Path)
+}
+
+// ref returns the git reference of the item, a commit takes precedence over a tag, and a tag over a branch.
+func (i IncludeItem) ref() string {
+	switch {
+	case i.Commit != "":
+		return i.Commit
+	case i.Tag != "":
+		return i.Tag
+	}
+	return i.Branch
+}
+
+// ValidateModular validates a modular bitrise.yml. The include items of the main file and of the included files
+// are resolved with the resolver, and the files are merged the way the Bitrise CLI does, before validating the result:
+//
+//   - the included files are merged in the order of the include list, a later file overrides the earlier ones,
+//   - the including file overrides the files it includes,
+//   - mappings are merged key by key, any other value (lists too) is overridden as a whole.
+//
+// Every issue is attributed to the file its offending value comes from by the issue's File field,
+// and it is located in that file. Include items of an included file without a repository refer to the repository
+// of the included file. Include cycles, include chains nested deeper than the limit set by WithMaxIncludeDepth
+// and duplicate includes are reported as issues, see AnalyzeIncludes.
+func (v JSONSchemaValidator) ValidateModular(name, ymlStr string, resolver IncludeResolver, warningPatterns ...string) ([]ValidationIssue, error) {
+	stream, err := ParseYAML(ymlStr)
+	if err != nil {
+		return nil, err
+	}
+	if v.rejectMultiDocument && len(stream.Documents) > 1 {
+		return nil, ErrMultiDocument
+	}
+
+	maxDepth := v.maxIncludeDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxIncludeDepth
+	}
+	main, loader := loadIncludeGraph(name, stream.Documents[0], resolver, maxDepth)
+
+	merged := main.merged()
+	if include, ok := main.value["include"]; ok {
+		merged.value["include"] = include
+	}
+
+	var documentIssues []ValidationIssue
+	for _, fileName := range loader.names {
+		file := loader.files[fileName]
+		for _, issue := range keyIssues(file.root) {
+			issue.File = file.name
+			documentIssues = append(documentIssues, issue)
+		}
+	}
+	documentIssues = append(documentIssues, loader.issues...)
+
+	issues, err := v.validateDocument(merged.value, 0, documentIssues, warningPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range issues {
+		if issues[i].File == "" {
+			issues[i].File = merged.origin(issues[i].InstancePtr, main.name)
+		}
+		if file, ok := loader.files[issues[i].File]; ok && issues[i].Line == 0 {
+			locateIssuesInRoots(issues[i:i+1], []*yamlv3.Node{file.root})
+		}
+	}
+
+	return issues, nil
+}
+
+// configFile is a file of a modular bitrise.yml.
+type configFile struct {
+	name     string
+	value    map[string]interface{}
+	root     *yamlv3.Node
+	includes []*configFile
+}
+
+func newConfigFile(name string, document *YAMLDocument) *configFile {
+	value, _ := document.Value.(map[string]interface{})
+	return &configFile{
+		name:  name,
+		value: value,
+		root:  document.Root,
+	}
+}
+
+type includeLoader struct {
+	resolver IncludeResolver
+	maxDepth int
+	files    map[string]*configFile
+	// names are the names of the main file and the included files, in the order they were loaded.
+	names []string
+	edges []IncludeEdge
+	// included maps the include keys to the include item the file was first included with.
+	included map[string]includeSite
+	// tooDeep are the include items reported for exceeding the depth limit, keyed by their file and pointer.
+	tooDeep map[string]bool
+	issues  []ValidationIssue
+}
+
+// loadIncludes resolves the include items of the file recursively.
+// includedWith is the item the file was included with, stack are the names of the files from the main file
+// down to the file.
+func (l *includeLoader) loadIncludes(file *configFile, includedWith *IncludeItem, stack []string) {
+	for _, item := range LoadBitriseYML(file.value).Include {
+		if item.Path == "" {
+			continue
+		}
+		if item.Repository == "" && includedWith != nil && includedWith.Repository != "" {
+			item.Repository, item.Branch, item.Commit, item.Tag = includedWith.Repository, includedWith.Branch, includedWith.Commit, includedWith.Tag
+		}
+
+		name, content, err := l.resolver.Resolve(item)
+		if err != nil {
+			l.issues = append(l.issues, unresolvedIncludeIssue(file.name, item, fmt.Sprintf("failed to resolve include %q: %s", item.Name(), err)))
+			continue
+		}
+		l.edges = append(l.edges, IncludeEdge{From: file.name, To: name, Item: item})
+
+		chain := append(append([]string{}, stack...), name)
+		if containsString(stack, name) {
+			l.issues = append(l.issues, includeCycleIssue(file.name, item, chain))
+			continue
+		}
+		if len(stack) > l.maxDepth {
+			l.reportDepth(file.name, item, chain)
+			continue
+		}
+		key := includeKey(item)
+		if first, ok := l.included[key]; ok {
+			l.issues = append(l.issues, duplicateIncludeIssue(file.name, item, first))
+		} else {
+			l.included[key] = includeSite{file: file.name, ptr: item.Ptr}
+		}
+
+		included, ok := l.files[name]
+		if !ok {
+			stream, err := ParseYAML(string(content))
+			if err != nil {
+				l.issues = append(l.issues, unresolvedIncludeIssue(file.name, item, fmt.Sprintf("failed to parse included file %q: %s", name, err)))
+				continue
+			}
+			included = newConfigFile(name, stream.Documents[0])
+			l.files[name] = included
+			l.names = append(l.names, name)
+			l.loadIncludes(included, &item, chain)
+		} else {
+			l.checkDepth(name, chain)
+		}
+		file.includes = append(file.includes, included)
+	}
+}
+
+// checkDepth reports the include items below an already loaded file which exceed the depth limit,
+// when the file is included again through a deeper chain. stack ends with the name of the file.
+func (l *includeLoader) checkDepth(name string, stack []string) {
+	for _, edge := range l.edges {
+		if edge.From != name || containsString(stack, edge.To) {
+			continue
+		}
+		chain := append(append([]string{}, stack...), edge.To)
+		if len(stack) > l.maxDepth {
+			l.reportDepth(edge.From, edge.Item, chain)
+			continue
+		}
+		l.checkDepth(edge.To, chain)
+	}
+}
+
+// reportDepth reports the include item exceeding the depth limit, once for the deepest chain found first.
+func (l *includeLoader) reportDepth(file string, item IncludeItem, chain []string) {
+	key := file + "\x00" + item.Ptr
+	if l.tooDeep[key] {
+		return
+	}
+	l.tooDeep[key] = true
+	l.issues = append(l.issues, includeDepthIssue(file, item, chain, l.maxDepth))
+}
+
+func unresolvedIncludeIssue(file string, item IncludeItem, message string) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: item.Ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordUnresolvedInclude),
+		Message:     message,
+		Keyword:     KeywordUnresolvedInclude,
+		File:        file,
+	}
+}
+
+// mergedConfig is the merged value of a file and the files it includes.
+type mergedConfig struct {
+	value map[string]interface{}
+	// origins maps the JSON pointers of the merged value to the name of the file they come from.
+	origins map[string]string
+}
+
+// merged returns the value of the file merged with the files it includes.
+func (f *configFile) merged() mergedConfig {
+	result := mergedConfig{value: map[string]interface{}{}, origins: map[string]string{}}
+	for _, included := range f.includes {
+		result.overlay(included.merged(), "#")
+	}
+
+	own := mergedConfig{value: map[string]interface{}{}, origins: map[string]string{}}
+	for key, value := range f.value {
+		if key == "include" {
+			continue
+		}
+		own.value[key] = copyValue(value)
+	}
+	recordOrigins(own.value, "#", f.name, own.origins)
+	result.overlay(own, "#")
+	result.origins["#"] = f.name
+
+	return result
+}
+
+// overlay merges the top config into the config, the values of top override the ones of the config.
+func (c *mergedConfig) overlay(top mergedConfig, ptr string) {
+	overlayMap(c.value, top.value, ptr, c.origins, top.origins)
+}
+
+func overlayMap(dst, src map[string]interface{}, ptr string, dstOrigins, srcOrigins map[string]string) {
+	for key, srcValue := range src {
+		keyPtr := joinPtr(ptr, key)
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		if dstIsMap && srcIsMap {
+			dstOrigins[keyPtr] = srcOrigins[keyPtr]
+			overlayMap(dstMap, srcMap, keyPtr, dstOrigins, srcOrigins)
+			continue
+		}
+
+		dst[key] = srcValue
+		for originPtr := range dstOrigins {
+			if isPtrWithin(originPtr, keyPtr) {
+				delete(dstOrigins, originPtr)
+			}
+		}
+		for originPtr, file := range srcOrigins {
+			if isPtrWithin(originPtr, keyPtr) {
+				dstOrigins[originPtr] = file
+			}
+		}
+	}
+}
+
+// origin returns the name of the file the value at the JSON pointer comes from, or the fallback if unknown.
+func (c mergedConfig) origin(ptr, fallback string) string {
+	for {
+		if file, ok := c.origins[ptr]; ok {
+			return file
+		}
+		idx := strings.LastIndex(ptr, "/")
+		if idx == -1 {
+			return fallback
+		}
+		ptr = ptr[:idx]
+	}
+}
+
+func recordOrigins(value interface{}, ptr, file string, origins map[string]string) {
+	origins[ptr] = file
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			recordOrigins(item, joinPtr(ptr, key), file, origins)
+		}
+	case []interface{}:
+		for idx, item := range value {
+			recordOrigins(item, joinPtr(ptr, fmt.Sprint(idx)), file, origins)
+		}
+	}
+}
+
+// isPtrWithin tells whether the JSON pointer is the parent pointer or one of its descendants.
+func isPtrWithin(ptr, parent string) bool {
+	return ptr == parent || strings.HasPrefix(ptr, parent+"/")
+}
+
+func copyValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for idx, item := range value {
+			copied[idx] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
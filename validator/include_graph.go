@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	KeywordIncludeCycle     = "includeCycle"
+	KeywordIncludeDepth     = "includeDepth"
+	KeywordDuplicateInclude = "duplicateInclude"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordIncludeCycle:     "Include cycle",
+	KeywordIncludeDepth:     "Include chain exceeding the depth limit",
+	KeywordDuplicateInclude: "File included more than once",
+})
+
+// DefaultMaxIncludeDepth is the number of nested include levels allowed below the main file by default.
+const DefaultMaxIncludeDepth = 5
+
+// WithMaxIncludeDepth sets the number of nested include levels ValidateModular allows below the main file.
+func WithMaxIncludeDepth(depth int) Option {
+	return func(v *JSONSchemaValidator) {
+		v.maxIncludeDepth = depth
+	}
+}
+
+// IncludeGraph is the graph of the files of a modular bitrise.yml.
+type IncludeGraph struct {
+	// Files are the names of the main file and the included files, in the order they were loaded.
+	Files []string
+	// Edges are the resolved include items, in the order they were resolved.
+	Edges []IncludeEdge
+}
+
+// IncludeEdge is an include item of the From file, resolved to the To file.
+type IncludeEdge struct {
+	From string
+	To   string
+	Item IncludeItem
+}
+
+// AnalyzeIncludes resolves the include graph of a modular bitrise.yml with the resolver and reports:
+//
+//   - include cycles, with the full chain of files from the main file,
+//   - include chains nested deeper than maxDepth levels below the main file,
+//   - files included more than once by the same path, repository and git reference.
+//
+// Include items which can't be resolved or parsed are reported too. The issues are reported at the include item
+// in the including file, the files taking part in a cycle or exceeding the depth limit are not followed further.
+func AnalyzeIncludes(name, ymlStr string, resolver IncludeResolver, maxDepth int) (*IncludeGraph, []ValidationIssue, error) {
+	stream, err := ParseYAML(ymlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, loader := loadIncludeGraph(name, stream.Documents[0], resolver, maxDepth)
+	issues := loader.issues
+	for i := range issues {
+		issues[i].Severity = SeverityError
+		locateIssuesInRoots(issues[i:i+1], []*yamlv3.Node{loader.files[issues[i].File].root})
+	}
+
+	return &IncludeGraph{Files: loader.names, Edges: loader.edges}, issues, nil
+}
+
+// loadIncludeGraph loads the main file and the files it includes recursively.
+// The name of the main file is cleaned, like the file system resolvers clean the names of the included files,
+// so an include of the main file is recognized whichever way its path was given, like ./bitrise.yml.
+func loadIncludeGraph(name string, document *YAMLDocument, resolver IncludeResolver, maxDepth int) (*configFile, *includeLoader) {
+	name = filepath.Clean(name)
+	main := newConfigFile(name, document)
+	loader := &includeLoader{
+		resolver: resolver,
+		maxDepth: maxDepth,
+		files:    map[string]*configFile{name: main},
+		names:    []string{name},
+		included: map[string]includeSite{},
+		tooDeep:  map[string]bool{},
+	}
+	loader.loadIncludes(main, nil, []string{name})
+	return main, loader
+}
+
+// includeSite is the include item a file was first included with.
+type includeSite struct {
+	file string
+	ptr  string
+}
+
+// includeKey identifies the file an include item refers to by its path, repository and git reference.
+func includeKey(item IncludeItem) string {
+	return strings.Join([]string{item.Repository, item.ref(), path.Clean(item.Path)}, "\x00")
+}
+
+func includeCycleIssue(file string, item IncludeItem, chain []string) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: item.Ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordIncludeCycle),
+		Message:     fmt.Sprintf("include cycle: %s", strings.Join(chain, " -> ")),
+		Keyword:     KeywordIncludeCycle,
+		File:        file,
+	}
+}
+
+func includeDepthIssue(file string, item IncludeItem, chain []string, maxDepth int) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: item.Ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordIncludeDepth),
+		Message:     fmt.Sprintf("include depth exceeds the limit of %d: %s", maxDepth, strings.Join(chain, " -> ")),
+		Keyword:     KeywordIncludeDepth,
+		File:        file,
+	}
+}
+
+func duplicateIncludeIssue(file string, item IncludeItem, first includeSite) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: item.Ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordDuplicateInclude),
+		Message:     fmt.Sprintf("%q is already included by %s at %s", item.Name(), first.file, first.ptr),
+		Keyword:     KeywordDuplicateInclude,
+		File:        file,
+	}
+}
@@ -0,0 +1,220 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestAnalyzeIncludes(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     MapIncludeResolver
+		main      string
+		maxDepth  int
+		wantFiles []string
+		want      []string
+	}{
+		{
+			name: "cycle",
+			files: MapIncludeResolver{
+				"a.yml": "include:\n- path: b.yml\n",
+				"b.yml": "include:\n- path: a.yml\n",
+			},
+			main:      "include:\n- path: a.yml\n",
+			maxDepth:  DefaultMaxIncludeDepth,
+			wantFiles: []string{"bitrise.yml", "a.yml", "b.yml"},
+			want:      []string{"b.yml 2:3 I[#/include/0] S[#/x-semantic/includeCycle] include cycle: bitrise.yml -> a.yml -> b.yml -> a.yml"},
+		},
+		{
+			name: "self include",
+			files: MapIncludeResolver{
+				"a.yml": "include:\n- path: a.yml\n",
+			},
+			main:      "include:\n- path: a.yml\n",
+			maxDepth:  DefaultMaxIncludeDepth,
+			wantFiles: []string{"bitrise.yml", "a.yml"},
+			want:      []string{"a.yml 2:3 I[#/include/0] S[#/x-semantic/includeCycle] include cycle: bitrise.yml -> a.yml -> a.yml"},
+		},
+		{
+			name: "depth limit",
+			files: MapIncludeResolver{
+				"a.yml": "include:\n- path: b.yml\n",
+				"b.yml": "include:\n- path: c.yml\n",
+				"c.yml": "workflows: {}\n",
+			},
+			main:      "include:\n- path: a.yml\n",
+			maxDepth:  2,
+			wantFiles: []string{"bitrise.yml", "a.yml", "b.yml"},
+			want:      []string{"b.yml 2:3 I[#/include/0] S[#/x-semantic/includeDepth] include depth exceeds the limit of 2: bitrise.yml -> a.yml -> b.yml -> c.yml"},
+		},
+		{
+			name: "depth limit through an already included file",
+			files: MapIncludeResolver{
+				"shared.yml": "include:\n- path: leaf.yml\n",
+				"leaf.yml":   "workflows: {}\n",
+				"a.yml":      "include:\n- path: shared.yml\n",
+			},
+			main:      "include:\n- path: shared.yml\n- path: a.yml\n",
+			maxDepth:  2,
+			wantFiles: []string{"bitrise.yml", "shared.yml", "leaf.yml", "a.yml"},
+			want: []string{
+				`a.yml 2:3 I[#/include/0] S[#/x-semantic/duplicateInclude] "shared.yml" is already included by bitrise.yml at #/include/0`,
+				"shared.yml 2:3 I[#/include/0] S[#/x-semantic/includeDepth] include depth exceeds the limit of 2: bitrise.yml -> a.yml -> shared.yml -> leaf.yml",
+			},
+		},
+		{
+			name: "diamond and repeated includes",
+			files: MapIncludeResolver{
+				"a.yml":      "include:\n- path: shared.yml\n",
+				"b.yml":      "include:\n- path: shared.yml\n",
+				"shared.yml": "workflows: {}\n",
+				"https://github.com/org/repo.git@main:shared.yml": "workflows: {}\n",
+			},
+			main: `include:
+- path: a.yml
+- path: b.yml
+- path: a.yml
+- path: shared.yml
+  repository: https://github.com/org/repo.git
+  branch: main
+`,
+			maxDepth:  DefaultMaxIncludeDepth,
+			wantFiles: []string{"bitrise.yml", "a.yml", "shared.yml", "b.yml", "https://github.com/org/repo.git@main:shared.yml"},
+			want: []string{
+				`b.yml 2:3 I[#/include/0] S[#/x-semantic/duplicateInclude] "shared.yml" is already included by a.yml at #/include/0`,
+				`bitrise.yml 4:3 I[#/include/2] S[#/x-semantic/duplicateInclude] "a.yml" is already included by bitrise.yml at #/include/0`,
+			},
+		},
+		{
+			name: "unresolved include",
+			files: MapIncludeResolver{
+				"a.yml": "include: [{path: missing.yml}]\n",
+			},
+			main:      "include:\n- path: a.yml\n",
+			maxDepth:  DefaultMaxIncludeDepth,
+			wantFiles: []string{"bitrise.yml", "a.yml"},
+			want:      []string{`a.yml 1:11 I[#/include/0] S[#/x-semantic/unresolvedInclude] failed to resolve include "missing.yml": file not found`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, issues, err := AnalyzeIncludes("bitrise.yml", tt.main, tt.files, tt.maxDepth)
+			if err != nil {
+				t.Fatalf("AnalyzeIncludes() error = %v", err)
+			}
+			if !reflect.DeepEqual(graph.Files, tt.wantFiles) {
+				t.Errorf("AnalyzeIncludes() files = %v, want %v", graph.Files, tt.wantFiles)
+			}
+
+			var got []string
+			for _, issue := range issues {
+				if issue.Severity != SeverityError {
+					t.Errorf("AnalyzeIncludes() issue severity = %s, want %s", issue.Severity, SeverityError)
+				}
+				got = append(got, fmt.Sprintf("%s %d:%d %s", issue.File, issue.Line, issue.Column, issue))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnalyzeIncludes() issues =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeIncludes_FileIncludeResolver(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"ci/a.yml": "include:\n- path: ci/b.yml\n",
+		"ci/b.yml": "include:\n- path: ci/a.yml\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph, issues, err := AnalyzeIncludes("bitrise.yml", "include:\n- path: ci/a.yml\n- path: ./ci/a.yml\n", FileIncludeResolver{Root: root}, DefaultMaxIncludeDepth)
+	if err != nil {
+		t.Fatalf("AnalyzeIncludes() error = %v", err)
+	}
+
+	a, b := filepath.Join(root, "ci/a.yml"), filepath.Join(root, "ci/b.yml")
+	wantEdges := []IncludeEdge{
+		{From: "bitrise.yml", To: a, Item: IncludeItem{Ptr: "#/include/0", Path: "ci/a.yml"}},
+		{From: a, To: b, Item: IncludeItem{Ptr: "#/include/0", Path: "ci/b.yml"}},
+		{From: b, To: a, Item: IncludeItem{Ptr: "#/include/0", Path: "ci/a.yml"}},
+		{From: "bitrise.yml", To: a, Item: IncludeItem{Ptr: "#/include/1", Path: "./ci/a.yml"}},
+	}
+	if !reflect.DeepEqual(graph.Edges, wantEdges) {
+		t.Errorf("AnalyzeIncludes() edges =\n%+v\nwant\n%+v", graph.Edges, wantEdges)
+	}
+	if len(issues) != 2 || issues[0].Keyword != KeywordIncludeCycle || issues[0].File != b {
+		t.Fatalf("AnalyzeIncludes() issues = %v, want a cycle issue in %s and a duplicate include", issues, b)
+	}
+	if issues[1].Keyword != KeywordDuplicateInclude || issues[1].InstancePtr != "#/include/1" {
+		t.Errorf("AnalyzeIncludes() issue = %v, want a duplicate include at #/include/1", issues[1])
+	}
+	if want := "include cycle: bitrise.yml -> " + a + " -> " + b + " -> " + a; issues[0].Message != want {
+		t.Errorf("AnalyzeIncludes() message = %s, want %s", issues[0].Message, want)
+	}
+
+	t.Run("main file name", func(t *testing.T) {
+		root := t.TempDir()
+		for name, content := range map[string]string{
+			"bitrise.yml": "include:\n- path: a.yml\n",
+			"a.yml":       "include:\n- path: bitrise.yml\n",
+		} {
+			if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(root); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := os.Chdir(wd); err != nil {
+				t.Fatal(err)
+			}
+		}()
+
+		graph, issues, err := AnalyzeIncludes("./bitrise.yml", "include:\n- path: a.yml\n", FileIncludeResolver{Root: "."}, DefaultMaxIncludeDepth)
+		if err != nil {
+			t.Fatalf("AnalyzeIncludes() error = %v", err)
+		}
+		if want := []string{"bitrise.yml", "a.yml"}; !reflect.DeepEqual(graph.Files, want) {
+			t.Errorf("AnalyzeIncludes() files = %v, want %v", graph.Files, want)
+		}
+		if len(issues) != 1 || issues[0].File != "a.yml" || issues[0].Message != "include cycle: bitrise.yml -> a.yml -> bitrise.yml" {
+			t.Errorf("AnalyzeIncludes() issues = %v, want the cycle closed by a.yml", issues)
+		}
+	})
+}
+
+func TestJSONSchemaValidator_ValidateModular_IncludeGraph(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.BitriseSchema, WithMaxIncludeDepth(1))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	resolver := MapIncludeResolver{
+		"a.yml": "include:\n- path: b.yml\n",
+		"b.yml": "workflows: {}\n",
+	}
+	issues, err := v.ValidateModular("bitrise.yml", "format_version: \"13\"\ninclude:\n- path: a.yml\n", resolver)
+	if err != nil {
+		t.Fatalf("ValidateModular() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Keyword != KeywordIncludeDepth || issues[0].File != "a.yml" || issues[0].Line != 2 {
+		t.Errorf("ValidateModular() = %v, want an include depth issue in a.yml", issues)
+	}
+}
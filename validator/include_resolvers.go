@@ -0,0 +1,120 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileIncludeResolver resolves the include items of the local repository from the file system.
+type FileIncludeResolver struct {
+	// Root is the root directory of the repository, the include paths are relative to it.
+	Root string
+}
+
+func (r FileIncludeResolver) Resolve(item IncludeItem) (string, []byte, error) {
+	if item.Repository != "" {
+		return "", nil, fmt.Errorf("repository %s is not available locally", item.Repository)
+	}
+	pth, err := joinUnderRoot(r.Root, item.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return "", nil, err
+	}
+	return pth, content, nil
+}
+
+// GitCheckoutIncludeResolver resolves the include items of other repositories from their local git checkouts.
+// Files of an item with a branch, tag or commit are read from that git reference of the checkout,
+// otherwise from the working tree.
+type GitCheckoutIncludeResolver struct {
+	// Checkouts maps the repository URLs to the directories of their local checkouts.
+	Checkouts map[string]string
+	// Local resolves the include items without a repository, they are not resolved if it is nil.
+	Local IncludeResolver
+}
+
+func (r GitCheckoutIncludeResolver) Resolve(item IncludeItem) (string, []byte, error) {
+	if item.Repository == "" {
+		if r.Local == nil {
+			return "", nil, fmt.Errorf("local includes are not supported")
+		}
+		return r.Local.Resolve(item)
+	}
+
+	dir, ok := r.Checkouts[item.Repository]
+	if !ok {
+		return "", nil, fmt.Errorf("no local checkout of repository %s", item.Repository)
+	}
+
+	ref := item.ref()
+	if strings.HasPrefix(ref, "-") {
+		return "", nil, fmt.Errorf("invalid git reference %q", ref)
+	}
+	if ref == "" {
+		pth, err := joinUnderRoot(dir, item.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return "", nil, err
+		}
+		return item.Name(), content, nil
+	}
+
+	pth := strings.TrimPrefix(path.Clean("/"+item.Path), "/")
+	content, err := gitShow(dir, ref, pth)
+	if err != nil && item.Commit == "" && item.Tag == "" {
+		// The branch might only exist as a remote tracking branch in the checkout.
+		if remoteContent, remoteErr := gitShow(dir, "origin/"+ref, pth); remoteErr == nil {
+			content, err = remoteContent, nil
+		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return item.Name(), content, nil
+}
+
+// joinUnderRoot joins the slash separated include path to the root directory,
+// and rejects paths pointing outside of the root, like ../secrets.yml.
+func joinUnderRoot(root, includePath string) (string, error) {
+	pth := filepath.Join(root, filepath.FromSlash(includePath))
+	rel, err := filepath.Rel(filepath.Clean(root), pth)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s points outside of the repository", includePath)
+	}
+	return pth, nil
+}
+
+// gitShow reads the file at the git reference of the checkout, the reference must not start with a dash.
+func gitShow(dir, ref, pth string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "show", ref+":"+pth)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git show %s:%s failed: %s", ref, pth, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// MapIncludeResolver resolves the include items from an in-memory map of file names to contents,
+// the file names are the names of the include items, see IncludeItem.Name.
+type MapIncludeResolver map[string]string
+
+func (r MapIncludeResolver) Resolve(item IncludeItem) (string, []byte, error) {
+	content, ok := r[item.Name()]
+	if !ok {
+		return "", nil, fmt.Errorf("file not found")
+	}
+	return item.Name(), []byte(content), nil
+}
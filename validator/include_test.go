@@ -0,0 +1,213 @@
+package validator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_ValidateModular(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.BitriseSchema, WithSemanticChecks(BitriseYMLSemanticChecks()...))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	resolver := MapIncludeResolver{
+		"ci/workflows.yml": `include:
+- path: ci/steps.yml
+workflows:
+  test:
+    summary: 1
+    before_run:
+    - setup
+  build:
+    after_run:
+    - undefined
+`,
+		"ci/steps.yml": `workflows:
+  setup:
+    steps:
+    - script@1: {}
+`,
+	}
+	issues, err := v.ValidateModular("bitrise.yml", `format_version: "13"
+include:
+- path: ci/workflows.yml
+- path: ci/missing.yml
+workflows:
+  test:
+    title: Test
+`, resolver)
+	if err != nil {
+		t.Fatalf("ValidateModular() error = %v", err)
+	}
+
+	type located struct {
+		File        string
+		InstancePtr string
+		Keyword     string
+		Line        int
+		Column      int
+	}
+	var got []located
+	for _, issue := range issues {
+		got = append(got, located{File: issue.File, InstancePtr: issue.InstancePtr, Keyword: issue.Keyword, Line: issue.Line, Column: issue.Column})
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].File+got[i].InstancePtr < got[j].File+got[j].InstancePtr
+	})
+	want := []located{
+		{File: "bitrise.yml", InstancePtr: "#/include/1", Keyword: KeywordUnresolvedInclude, Line: 4, Column: 3},
+		{File: "ci/workflows.yml", InstancePtr: "#/workflows/build/after_run/0", Keyword: KeywordUndefinedWorkflow, Line: 10, Column: 7},
+		{File: "ci/workflows.yml", InstancePtr: "#/workflows/test/summary", Keyword: "type", Line: 5, Column: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateModular() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func Test_configFile_merged(t *testing.T) {
+	first := &configFile{name: "first.yml", value: map[string]interface{}{
+		"app":       map[string]interface{}{"envs": []interface{}{"A"}, "title": "first"},
+		"workflows": map[string]interface{}{"a": map[string]interface{}{"title": "first"}},
+	}}
+	second := &configFile{name: "second.yml", value: map[string]interface{}{
+		"app": map[string]interface{}{"envs": []interface{}{"B"}},
+	}}
+	main := &configFile{name: "bitrise.yml", includes: []*configFile{first, second}, value: map[string]interface{}{
+		"include":   []interface{}{},
+		"workflows": map[string]interface{}{"a": map[string]interface{}{"summary": "main"}},
+	}}
+
+	merged := main.merged()
+	want := map[string]interface{}{
+		"app":       map[string]interface{}{"envs": []interface{}{"B"}, "title": "first"},
+		"workflows": map[string]interface{}{"a": map[string]interface{}{"title": "first", "summary": "main"}},
+	}
+	if !reflect.DeepEqual(merged.value, want) {
+		t.Errorf("merged() = %v, want %v", merged.value, want)
+	}
+
+	origins := map[string]string{
+		"#":                       "bitrise.yml",
+		"#/app/envs/0":            "second.yml",
+		"#/app/title":             "first.yml",
+		"#/workflows/a/title":     "first.yml",
+		"#/workflows/a/summary":   "bitrise.yml",
+		"#/workflows/a/steps/0/x": "bitrise.yml",
+	}
+	for ptr, wantFile := range origins {
+		if got := merged.origin(ptr, ""); got != wantFile {
+			t.Errorf("origin(%s) = %s, want %s", ptr, got, wantFile)
+		}
+	}
+	if first.value["app"].(map[string]interface{})["envs"].([]interface{})[0] != "A" {
+		t.Errorf("merged() modified the included file's value")
+	}
+}
+
+func TestGitCheckoutIncludeResolver(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s %s", args, err, out)
+		}
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	if err := os.MkdirAll(filepath.Join(dir, "ci"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pth := filepath.Join(dir, "ci", "steps.yml")
+	if err := os.WriteFile(pth, []byte("committed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "ci/steps.yml")
+	git("commit", "--quiet", "-m", "Add steps")
+	if err := os.WriteFile(pth, []byte("working tree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repository := "https://github.com/org/shared.git"
+	resolver := GitCheckoutIncludeResolver{
+		Checkouts: map[string]string{repository: dir},
+		Local:     MapIncludeResolver{"bitrise.yml": "local\n"},
+	}
+	tests := []struct {
+		name        string
+		item        IncludeItem
+		wantName    string
+		wantContent string
+		wantErr     bool
+	}{
+		{name: "branch", item: IncludeItem{Repository: repository, Branch: "main", Path: "ci/steps.yml"}, wantName: repository + "@main:ci/steps.yml", wantContent: "committed\n"},
+		{name: "working tree", item: IncludeItem{Repository: repository, Path: "ci/steps.yml"}, wantName: repository + ":ci/steps.yml", wantContent: "working tree\n"},
+		{name: "local", item: IncludeItem{Path: "bitrise.yml"}, wantName: "bitrise.yml", wantContent: "local\n"},
+		{name: "missing ref", item: IncludeItem{Repository: repository, Tag: "v1.0.0", Path: "ci/steps.yml"}, wantErr: true},
+		{name: "unknown repository", item: IncludeItem{Repository: "https://github.com/org/other.git", Path: "ci/steps.yml"}, wantErr: true},
+		{name: "path outside of the checkout", item: IncludeItem{Repository: repository, Path: "../outside.yml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, content, err := resolver.Resolve(tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || string(content) != tt.wantContent {
+				t.Errorf("Resolve() = %s, %q, want %s, %q", name, content, tt.wantName, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestGitCheckoutIncludeResolver_OptionRef(t *testing.T) {
+	dir, out := t.TempDir(), t.TempDir()
+	cmd := exec.Command("git", "-C", dir, "init", "--quiet")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %s %s", err, output)
+	}
+
+	repository := "https://github.com/org/shared.git"
+	resolver := GitCheckoutIncludeResolver{Checkouts: map[string]string{repository: dir}}
+	pwned := filepath.Join(out, "PWNED")
+	for _, item := range []IncludeItem{
+		{Repository: repository, Tag: "--output=" + pwned, Path: "ci.yml"},
+		{Repository: repository, Branch: "--output=" + pwned, Path: "ci.yml"},
+		{Repository: repository, Commit: "-p", Path: "ci.yml"},
+	} {
+		if _, _, err := resolver.Resolve(item); err == nil {
+			t.Errorf("Resolve(%+v) error = nil, want an invalid git reference error", item)
+		}
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Resolve() created files through the git reference: %v", entries)
+	}
+}
+
+func TestFileIncludeResolver_OutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "repo")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.yml"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := FileIncludeResolver{Root: root}
+	for _, pth := range []string{"../secret.yml", "ci/../../secret.yml"} {
+		if _, content, err := resolver.Resolve(IncludeItem{Path: pth}); err == nil {
+			t.Errorf("Resolve(%s) = %q, want an error", pth, content)
+		}
+	}
+}
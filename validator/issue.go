@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// ValidationIssue is a single schema violation found in a validated document.
+type ValidationIssue struct {
+	// InstancePtr is the JSON pointer of the offending value in the validated document, like #/inputs/0.
+	InstancePtr string
+	// SchemaPtr is the JSON pointer of the failing schema keyword, like #/properties/title/type.
+	// Issues the JSON schema can't express have a pseudo pointer naming their keyword, like #/x-semantic/undefinedWorkflow.
+	SchemaPtr string
+	// Message describes the violation.
+	Message string
+	// Keyword is the schema keyword that failed, like type, required or pattern.
+	Keyword  string
+	Severity Severity
+	// Line and Column locate the offending key or value in the YAML source, both are 1-based.
+	// They are 0 if the location is unknown.
+	Line   int
+	Column int
+	// DefinitionLine and DefinitionColumn locate the offending key or value where it is defined, if it is used
+	// through a YAML alias or merge key. Line and Column locate the alias then. Both are 0 otherwise.
+	DefinitionLine   int
+	DefinitionColumn int
+	// File is the name of the file the issue was found in, when validating a modular bitrise.yml with its includes.
+	// It is empty otherwise.
+	File string
+	// Document is the 0-based index of the document in a multi-document YAML stream.
+	Document int
+	// Suggestions are the valid property names or enum values nearest to the offending ones,
+	// for additionalProperties and enum issues.
+	Suggestions []string
+}
+
+// String renders the issue in the I[<instance pointer>] S[<schema pointer>] <message> form.
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("I[%s] S[%s] %s", i.InstancePtr, i.SchemaPtr, i.Message)
+}
+
+// semanticSchemaPtrPrefix is the prefix of the pseudo schema pointers of the issues the JSON schema can't express,
+// like #/x-semantic/undefinedWorkflow, so that every issue renders with a schema pointer ending in its keyword.
+const semanticSchemaPtrPrefix = "#/x-semantic/"
+
+func semanticSchemaPtr(keyword string) string {
+	return semanticSchemaPtrPrefix + keyword
+}
+
+func keywordFromSchemaPtr(schemaPtr string) string {
+	idx := strings.LastIndex(schemaPtr, "/")
+	if idx == -1 {
+		return ""
+	}
+	return schemaPtr[idx+1:]
+}
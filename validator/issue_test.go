@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_ValidateIssues(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	stepYML := `
+title: 
+summary: Run any custom script you want. The power is in your hands. Use it wisely!
+website: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
+	issues, err := v.ValidateIssues(stepYML, `S\[#/required\]`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+
+	want := []ValidationIssue{
+		{
+			InstancePtr: "#",
+			SchemaPtr:   "#/required",
+			Message:     `missing properties: "source_code_url"`,
+			Keyword:     "required",
+			Severity:    SeverityWarning,
+			Line:        2,
+			Column:      1,
+		},
+		{
+			InstancePtr: "#/title",
+			SchemaPtr:   "#/properties/title/type",
+			Message:     "expected string, but got null",
+			Keyword:     "type",
+			Severity:    SeverityError,
+			Line:        2,
+			Column:      1,
+		},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateIssues() got = %#v, want %#v", issues, want)
+	}
+}
+
+func TestValidationIssue_String(t *testing.T) {
+	issue := ValidationIssue{InstancePtr: "#/title", SchemaPtr: "#/properties/title/type", Message: "expected string, but got null"}
+	if got, want := issue.String(), "I[#/title] S[#/properties/title/type] expected string, but got null"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
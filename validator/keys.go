@@ -0,0 +1,156 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	// KeywordDuplicateKey is the keyword of issues reporting a mapping key defined more than once.
+	KeywordDuplicateKey = "duplicateKey"
+	// KeywordNonStringKey is the keyword of issues reporting a mapping key which isn't a string, like 1, true or on.
+	KeywordNonStringKey = "nonStringKey"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordDuplicateKey: "Duplicate mapping key",
+	KeywordNonStringKey: "Mapping key which is not a string",
+})
+
+// mergeKey is the YAML merge key, it can occur multiple times in the same mapping.
+const mergeKey = "<<"
+
+// keyIssues reports the duplicate and the non-string mapping keys of the document.
+func keyIssues(root *yamlv3.Node) []ValidationIssue {
+	return nonStringKeyIssues(root, "#", duplicateKeyIssues(root, "#", nil))
+}
+
+// nonStringKeyIssue reports the key as it is written in the source, like on, with the type it is decoded into.
+func nonStringKeyIssue(ptr string, key *yamlv3.Node, decoded interface{}) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: joinPtr(ptr, key.Value),
+		SchemaPtr:   semanticSchemaPtr(KeywordNonStringKey),
+		Message:     fmt.Sprintf("map key %s (%T) is not a string", key.Value, decoded),
+		Keyword:     KeywordNonStringKey,
+	}
+}
+
+// nonStringKeyIssues reports every mapping key which is not a string with the YAML 1.1 semantics the documents
+// are decoded with, like 1 or an unquoted on. The values of these keys are left out of the decoded document,
+// so they are not checked any further. Keys of merged mappings are reported where the mapping is defined.
+func nonStringKeyIssues(node *yamlv3.Node, ptr string, issues []ValidationIssue) []ValidationIssue {
+	if node == nil {
+		return issues
+	}
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				sources := []*yamlv3.Node{value}
+				if value.Kind == yamlv3.SequenceNode {
+					sources = value.Content
+				}
+				for _, source := range sources {
+					if source.Kind == yamlv3.MappingNode {
+						issues = nonStringKeyIssues(source, ptr, issues)
+					}
+				}
+				continue
+			}
+			for key.Kind == yamlv3.AliasNode && key.Alias != nil {
+				key = key.Alias
+			}
+			if key.Kind != yamlv3.ScalarNode {
+				continue
+			}
+			if decoded := decodeYAML11Key(key); !isString(decoded) {
+				issues = append(issues, nonStringKeyIssue(ptr, key, decoded))
+				continue
+			}
+			issues = nonStringKeyIssues(value, joinPtr(ptr, key.Value), issues)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			issues = nonStringKeyIssues(item, joinPtr(ptr, fmt.Sprint(i)), issues)
+		}
+	}
+
+	return issues
+}
+
+// decodeYAML11Key decodes the scalar key the way yaml.v2 does. Quoted and block scalars are strings,
+// plain scalars are resolved with YAML 1.1 semantics, so on is decoded as true.
+// The key is decoded as the key of a mapping, so that a plain key like bundle:: isn't read as a mapping itself.
+func decodeYAML11Key(key *yamlv3.Node) interface{} {
+	if key.Style&^yamlv3.TaggedStyle != 0 {
+		return key.Value
+	}
+	text := key.Value
+	if key.Style&yamlv3.TaggedStyle != 0 {
+		text = key.ShortTag() + " " + text
+	}
+	var decoded map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(text+": null"), &decoded); err != nil || len(decoded) != 1 {
+		return key.Value
+	}
+	for decodedKey := range decoded {
+		return decodedKey
+	}
+	return key.Value
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+// duplicateKeyIssues reports every mapping key which was already defined earlier in the same mapping.
+// The issues are located at the duplicate keys.
+func duplicateKeyIssues(node *yamlv3.Node, ptr string, issues []ValidationIssue) []ValidationIssue {
+	if node == nil {
+		return issues
+	}
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		firstKeys := map[string]*yamlv3.Node{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yamlv3.ScalarNode && key.Value != mergeKey {
+				id := key.ShortTag() + ":" + key.Value
+				if first, ok := firstKeys[id]; ok {
+					issues = append(issues, ValidationIssue{
+						InstancePtr: joinPtr(ptr, key.Value),
+						SchemaPtr:   semanticSchemaPtr(KeywordDuplicateKey),
+						Message:     fmt.Sprintf("duplicate key %q, first defined at line %d", key.Value, first.Line),
+						Keyword:     KeywordDuplicateKey,
+						Line:        key.Line,
+						Column:      key.Column,
+					})
+				} else {
+					firstKeys[id] = key
+				}
+			}
+			issues = duplicateKeyIssues(value, joinPtr(ptr, key.Value), issues)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			issues = duplicateKeyIssues(item, joinPtr(ptr, fmt.Sprint(i)), issues)
+		}
+	}
+
+	return issues
+}
+
+// joinPtr appends the escaped reference token to the JSON pointer, the same way the schema validator does.
+func joinPtr(ptr, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return ptr + "/" + url.PathEscape(token)
+}
@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_ValidateIssues_Keys(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	stepYML := `title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+title: Script 2
+inputs:
+- content: ""
+  opts:
+    title: Script content
+    summary: Type your script here.
+    1: one
+on: true
+`
+	issues, err := v.ValidateIssues(stepYML)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+
+	want := []ValidationIssue{
+		{
+			InstancePtr: "#/title",
+			SchemaPtr:   semanticSchemaPtr(KeywordDuplicateKey),
+			Message:     `duplicate key "title", first defined at line 1`,
+			Keyword:     KeywordDuplicateKey,
+			Severity:    SeverityError,
+			Line:        6,
+			Column:      1,
+		},
+		{
+			InstancePtr: "#/inputs/0/opts/1",
+			SchemaPtr:   semanticSchemaPtr(KeywordNonStringKey),
+			Message:     "map key 1 (int) is not a string",
+			Keyword:     KeywordNonStringKey,
+			Severity:    SeverityError,
+			Line:        12,
+			Column:      5,
+		},
+		{
+			InstancePtr: "#/on",
+			SchemaPtr:   semanticSchemaPtr(KeywordNonStringKey),
+			Message:     "map key on (bool) is not a string",
+			Keyword:     KeywordNonStringKey,
+			Severity:    SeverityError,
+			Line:        13,
+			Column:      1,
+		},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateIssues() got = %#v, want %#v", issues, want)
+	}
+
+	documentIssues, err := v.ValidateDocuments(stepYML + "---\n" + stepYML)
+	if err != nil {
+		t.Fatalf("ValidateDocuments() error = %v", err)
+	}
+	if len(documentIssues) != 2*len(want) || documentIssues[len(want)].Document != 1 || documentIssues[len(want)].Line != 20 {
+		t.Errorf("ValidateDocuments() got = %#v", documentIssues)
+	}
+}
+
+func TestNonStringKeyIssues(t *testing.T) {
+	roots, err := parseNodeTrees([]byte(`"on": quoted
+bundle::: colons
+!!str 2: tagged
+base: &base
+  3.5: float
+merged:
+  <<: [*base, {~: null}]
+  yes: {4: nested}
+`))
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("parseNodeTrees() = %d roots, error = %v", len(roots), err)
+	}
+
+	var got []string
+	for _, issue := range nonStringKeyIssues(roots[0], "#", nil) {
+		got = append(got, issue.InstancePtr+" "+issue.Message)
+	}
+	want := []string{
+		"#/base/3.5 map key 3.5 (float64) is not a string",
+		"#/merged/~0 map key ~ (<nil>) is not a string",
+		"#/merged/yes map key yes (bool) is not a string",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nonStringKeyIssues() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,352 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HumanMessage is a domain specific, human-readable rendering of a validation issue.
+type HumanMessage struct {
+	// Text describes the issue and its location, like: workflow `test`, step 1: unknown key `with`.
+	Text string
+	// Hint suggests how to fix the issue, it is empty if there is no hint for the issue.
+	Hint string
+	// DocsURL links the documentation of the failing part of the document, it is empty if unknown.
+	DocsURL string
+}
+
+func (m HumanMessage) String() string {
+	str := m.Text
+	if m.Hint != "" {
+		str += "\n  hint: " + m.Hint
+	}
+	if m.DocsURL != "" {
+		str += "\n  docs: " + m.DocsURL
+	}
+	return str
+}
+
+// MessageRule attaches a hint and a docs link to the issues of a schema definition.
+type MessageRule struct {
+	// Definition is the name of the schema definition the rule applies to, like WorkflowModel.
+	// An empty definition matches issues of the schema root properties.
+	Definition string
+	// Keyword restricts the rule to issues of the given schema keyword, an empty keyword matches any issue.
+	Keyword string
+	Hint    string
+	DocsURL string
+}
+
+// MessageRenderer renders validation issues as human-readable messages of a given document kind.
+// The zero value renders the issues without domain specific locations, hints and docs links.
+type MessageRenderer struct {
+	describeLocation func(tokens []string) string
+	rules            []MessageRule
+}
+
+// Render returns the human-readable message of the issue.
+// Rules are matched in order: the first rule matching both the definition and the keyword wins,
+// and the first rule matching the definition with an empty keyword is the fallback.
+func (r MessageRenderer) Render(issue ValidationIssue) HumanMessage {
+	message := HumanMessage{Text: humanReadableMessage(issue)}
+	if len(issue.Suggestions) > 0 {
+		message.Text += fmt.Sprintf(", did you mean `%s`?", strings.Join(issue.Suggestions, "` or `"))
+	}
+	location := issue.InstancePtr
+	if r.describeLocation != nil {
+		location = r.describeLocation(splitPtr(issue.InstancePtr))
+	}
+	if location != "" {
+		message.Text = location + ": " + message.Text
+	}
+
+	definition := definitionFromSchemaPtr(issue.SchemaPtr)
+	var fallback *MessageRule
+	for i, rule := range r.rules {
+		if rule.Definition != definition {
+			continue
+		}
+		if rule.Keyword == issue.Keyword {
+			message.Hint, message.DocsURL = rule.Hint, rule.DocsURL
+			return message
+		}
+		if rule.Keyword == "" && fallback == nil {
+			fallback = &r.rules[i]
+		}
+	}
+	if fallback != nil {
+		message.Hint, message.DocsURL = fallback.Hint, fallback.DocsURL
+	}
+
+	return message
+}
+
+const (
+	bitriseYMLDocsURL         = "https://devcenter.bitrise.io/en/references/basics-of-bitrise-yml.html"
+	bitriseWorkflowsDocsURL   = "https://devcenter.bitrise.io/en/steps-and-workflows/introduction-to-workflows.html"
+	bitriseStepsDocsURL       = "https://devcenter.bitrise.io/en/steps-and-workflows/introduction-to-steps.html"
+	bitrisePipelinesDocsURL   = "https://devcenter.bitrise.io/en/builds/build-pipelines.html"
+	bitriseTriggersDocsURL    = "https://devcenter.bitrise.io/en/builds/starting-builds/triggering-builds-automatically.html"
+	bitriseContainersDocsURL  = "https://devcenter.bitrise.io/en/builds/containerization.html"
+	bitriseEnvVarsDocsURL     = "https://devcenter.bitrise.io/en/builds/environment-variables.html"
+	bitriseStepDevelopDocsURL = "https://devcenter.bitrise.io/en/steps-and-workflows/developing-your-own-bitrise-step/developing-a-new-step.html"
+	bitriseStepInputsDocsURL  = "https://devcenter.bitrise.io/en/steps-and-workflows/developing-your-own-bitrise-step/developing-a-new-step.html#step-inputs"
+	bitriseStepBundlesDocsURL = "https://devcenter.bitrise.io/en/steps-and-workflows/step-bundles.html"
+	bitriseModularYMLDocsURL  = "https://devcenter.bitrise.io/en/builds/configuration-yaml/modular-yaml-configuration.html"
+)
+
+// NewBitriseYMLMessageRenderer returns a renderer for the issues of bitrise.yml files.
+func NewBitriseYMLMessageRenderer() *MessageRenderer {
+	return &MessageRenderer{
+		describeLocation: describeBitriseYMLLocation,
+		rules: []MessageRule{
+			{Definition: "BitriseDataModel", Keyword: "additionalProperties", Hint: "check the spelling of the top level keys, like workflows, pipelines or trigger_map", DocsURL: bitriseYMLDocsURL},
+			{Definition: "BitriseDataModel", Keyword: "anyOf", Hint: "a bitrise.yml needs a format_version, unless it only includes other files", DocsURL: bitriseModularYMLDocsURL},
+			{Definition: "BitriseDataModel", DocsURL: bitriseYMLDocsURL},
+			{Definition: "WorkflowModel", Keyword: "additionalProperties", Hint: "steps have to be listed under the steps key of the workflow", DocsURL: bitriseWorkflowsDocsURL},
+			{Definition: "WorkflowModel", DocsURL: bitriseWorkflowsDocsURL},
+			{Definition: "StepModel", Keyword: "additionalProperties", Hint: "step inputs have to be listed under the inputs key of the step", DocsURL: bitriseStepsDocsURL},
+			{Definition: "StepModel", DocsURL: bitriseStepsDocsURL},
+			{Definition: "WithModel", Keyword: "additionalProperties", Hint: "a with group can only define a container, services and steps", DocsURL: bitriseContainersDocsURL},
+			{Definition: "WithModel", DocsURL: bitriseContainersDocsURL},
+			{Definition: "ContainerModel", DocsURL: bitriseContainersDocsURL},
+			{Definition: "DockerCredentialModel", Hint: "container credentials need a username and a password", DocsURL: bitriseContainersDocsURL},
+			{Definition: "PipelineModel", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "GraphPipelineWorkflowModel", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "StageModel", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "WorkflowStageConfigModel", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "TriggerMapItemModel", Keyword: "oneOf", Hint: "a trigger condition is either a string pattern or a {regex: ...} map", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "TriggerMapItemModel", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "TriggerMapItemModelRegexCondition", Hint: "a trigger condition is either a string pattern or a {regex: ...} map", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "TriggersModel", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "PushTriggerModel", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "PullrequestTriggerModel", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "TagTriggerModel", DocsURL: bitriseTriggersDocsURL},
+			{Definition: "EnvModel", Hint: "env vars are listed as single key maps, like - MY_KEY: value", DocsURL: bitriseEnvVarsDocsURL},
+			{Definition: "StepBundleModel", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "StepBundleOverrideModel", Keyword: "additionalProperties", Hint: "a step bundle reference can only override the title, summary, description, envs and inputs", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "StepBundleOverrideModel", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "IncludeItemModel", DocsURL: bitriseModularYMLDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedWorkflow, Hint: "check the spelling of the workflow name", DocsURL: bitriseWorkflowsDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedPipeline, Hint: "check the spelling of the pipeline name", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedStage, Hint: "check the spelling of the stage name", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedDependency, Hint: "depends_on can only list workflows of the same pipeline", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "", Keyword: KeywordSelfDependency, Hint: "remove the workflow from its own depends_on list", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "", Keyword: KeywordRecursiveWorkflow, Hint: "a workflow can't run itself through its before_run or after_run workflows", DocsURL: bitriseWorkflowsDocsURL},
+			{Definition: "", Keyword: KeywordDependencyCycle, Hint: "the workflows of a pipeline have to form a directed acyclic graph, remove one of the dependencies", DocsURL: bitrisePipelinesDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedStepBundle, Hint: "check the spelling of the step bundle name, or define it under step_bundles", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "", Keyword: KeywordUnknownBundleInput, Hint: "a step bundle reference can only override the inputs the step bundle declares", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "", Keyword: KeywordStepBundleCycle, Hint: "a step bundle can't run itself through its step bundle references", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "", Keyword: KeywordMalformedStepBundleReference, Hint: "reference the step bundle as bundle::<name>", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "", Keyword: KeywordUnusedStepBundle, Hint: "remove the step bundle, or reference it from a workflow as bundle::<name>", DocsURL: bitriseStepBundlesDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedContainer, Hint: "check the spelling of the container name, or define it under containers", DocsURL: bitriseContainersDocsURL},
+			{Definition: "", Keyword: KeywordUndefinedService, Hint: "check the spelling of the service name, or define it under services", DocsURL: bitriseContainersDocsURL},
+			{Definition: "", Keyword: KeywordInvalidImage, Hint: "use the [registry/]repository[:tag][@digest] form, like golang:1.21 or ghcr.io/org/image:1.0", DocsURL: bitriseContainersDocsURL},
+			{Definition: "", Keyword: KeywordInvalidPortMapping, Hint: "map the ports as host:container, like 5432:5432", DocsURL: bitriseContainersDocsURL},
+			{Definition: "", Keyword: KeywordInvalidCredentials, Hint: "set the username, the password (preferably as a secret, like $DOCKER_PASSWORD) and the registry server of the image", DocsURL: bitriseContainersDocsURL},
+			{Definition: "", Keyword: KeywordDuplicateKey, Hint: "only the last definition is used, remove or rename the others"},
+			{Definition: "", Keyword: KeywordNonStringKey, Hint: "quote the key, unquoted on, off, yes, no, true, false and numbers are not strings"},
+		},
+	}
+}
+
+// NewStepYMLMessageRenderer returns a renderer for the issues of step.yml files.
+func NewStepYMLMessageRenderer() *MessageRenderer {
+	return &MessageRenderer{
+		describeLocation: describeStepYMLLocation,
+		rules: []MessageRule{
+			{Definition: "", Keyword: "required", Hint: "title, summary, website, source_code_url and support_url are required", DocsURL: bitriseStepDevelopDocsURL},
+			{Definition: "", Keyword: "pattern", Hint: "the summary has to be a single line of at most 100 characters, use the description for details", DocsURL: bitriseStepDevelopDocsURL},
+			{Definition: "", Keyword: "additionalProperties", Hint: "check the spelling of the key", DocsURL: bitriseStepDevelopDocsURL},
+			{Definition: "", Keyword: KeywordDuplicateKey, Hint: "only the last definition is used, remove or rename the others"},
+			{Definition: "", Keyword: KeywordNonStringKey, Hint: "quote the key, unquoted on, off, yes, no, true, false and numbers are not strings"},
+			{Definition: "", DocsURL: bitriseStepDevelopDocsURL},
+			{Definition: "EnvVarOpts", Keyword: "required", Hint: "every input and output needs a title and a summary in its opts", DocsURL: bitriseStepInputsDocsURL},
+			{Definition: "EnvVarOpts", DocsURL: bitriseStepInputsDocsURL},
+			{Definition: "InputEnvVar", DocsURL: bitriseStepInputsDocsURL},
+			{Definition: "OutputEnvVar", DocsURL: bitriseStepInputsDocsURL},
+		},
+	}
+}
+
+// definitionFromSchemaPtr returns the schema definition name of pointers like #/definitions/WorkflowModel/properties/steps.
+func definitionFromSchemaPtr(schemaPtr string) string {
+	tokens := splitPtr(schemaPtr)
+	if len(tokens) >= 2 && tokens[0] == "definitions" {
+		return tokens[1]
+	}
+	return ""
+}
+
+var quotedNamesPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// humanReadableMessage rewrites the validator message of the common schema keywords.
+func humanReadableMessage(issue ValidationIssue) string {
+	switch issue.Keyword {
+	case "additionalProperties":
+		if names := quotedNames(issue.Message); len(names) == 1 {
+			return fmt.Sprintf("unknown key `%s`", names[0])
+		} else if len(names) > 1 {
+			return fmt.Sprintf("unknown keys `%s`", strings.Join(names, "`, `"))
+		}
+	case "required":
+		if names := quotedNames(issue.Message); len(names) == 1 {
+			return fmt.Sprintf("missing required key `%s`", names[0])
+		} else if len(names) > 1 {
+			return fmt.Sprintf("missing required keys `%s`", strings.Join(names, "`, `"))
+		}
+	case "type":
+		var expected, got string
+		if _, err := fmt.Sscanf(strings.Replace(issue.Message, ",", "", 1), "expected %s but got %s", &expected, &got); err == nil {
+			if got == "null" {
+				return fmt.Sprintf("value is empty, expected %s", article(expected))
+			}
+			return fmt.Sprintf("expected %s, but got %s", article(expected), article(got))
+		}
+	case "enum":
+		return strings.Replace(issue.Message, "value must be", "invalid value, it must be", 1)
+	case "pattern":
+		return strings.Replace(issue.Message, "does not match pattern", "value doesn't match the expected format", 1)
+	}
+	return issue.Message
+}
+
+func quotedNames(message string) []string {
+	var names []string
+	for _, match := range quotedNamesPattern.FindAllStringSubmatch(message, -1) {
+		name, err := strconv.Unquote(`"` + match[1] + `"`)
+		if err != nil {
+			name = match[1]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func article(jsonType string) string {
+	switch jsonType {
+	case "array", "integer", "object":
+		return "an " + jsonType
+	case "null":
+		return "null"
+	}
+	return "a " + jsonType
+}
+
+// describeBitriseYMLLocation describes the bitrise.yml element the pointer tokens refer to,
+// like: workflow `test`, step 2 (`script@1`), input `content`.
+func describeBitriseYMLLocation(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	var parts []string
+	rest := tokens[1:]
+	switch tokens[0] {
+	case "workflows", "step_bundles", "pipelines", "stages", "containers", "services":
+		kind := map[string]string{
+			"workflows":    "workflow",
+			"step_bundles": "step bundle",
+			"pipelines":    "pipeline",
+			"stages":       "stage",
+			"containers":   "container",
+			"services":     "service",
+		}[tokens[0]]
+		if len(rest) == 0 {
+			return "`" + tokens[0] + "`"
+		}
+		parts = append(parts, fmt.Sprintf("%s `%s`", kind, rest[0]))
+		rest = rest[1:]
+	case "trigger_map", "include":
+		if len(rest) == 0 {
+			return "`" + tokens[0] + "`"
+		}
+		parts = append(parts, fmt.Sprintf("%s item %s", tokens[0], ordinal(rest[0])))
+		rest = rest[1:]
+	default:
+		parts = append(parts, "`"+tokens[0]+"`")
+	}
+
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == "steps" && len(rest) > 1:
+			step := "step " + ordinal(rest[1])
+			rest = rest[2:]
+			if len(rest) > 0 {
+				if rest[0] == "with" {
+					step += " (`with` group)"
+				} else {
+					step += fmt.Sprintf(" (`%s`)", rest[0])
+				}
+				rest = rest[1:]
+			}
+			parts = append(parts, step)
+		case (rest[0] == "stages" || rest[0] == "workflows") && len(rest) > 2 && isIndex(rest[1]):
+			parts = append(parts, fmt.Sprintf("%s `%s`", strings.TrimSuffix(rest[0], "s"), rest[2]))
+			rest = rest[3:]
+		case rest[0] == "workflows" && len(rest) > 1:
+			parts = append(parts, fmt.Sprintf("workflow `%s`", rest[1]))
+			rest = rest[2:]
+		case (rest[0] == "inputs" || rest[0] == "envs" || rest[0] == "outputs") && len(rest) > 2 && isIndex(rest[1]):
+			parts = append(parts, fmt.Sprintf("%s `%s`", envListItemKind(rest[0]), rest[2]))
+			rest = rest[3:]
+		default:
+			parts = append(parts, "`"+strings.Join(rest, ".")+"`")
+			rest = nil
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// describeStepYMLLocation describes the step.yml element the pointer tokens refer to, like: input `content`, `opts.title`.
+func describeStepYMLLocation(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	if (tokens[0] == "inputs" || tokens[0] == "outputs") && len(tokens) > 1 && isIndex(tokens[1]) {
+		kind := envListItemKind(tokens[0])
+		if len(tokens) > 2 && tokens[2] != "opts" {
+			location := fmt.Sprintf("%s `%s`", kind, tokens[2])
+			if len(tokens) > 3 {
+				location += ", `" + strings.Join(tokens[3:], ".") + "`"
+			}
+			return location
+		}
+
+		location := fmt.Sprintf("%s %s", kind, ordinal(tokens[1]))
+		if len(tokens) > 2 {
+			location += ", `" + strings.Join(tokens[2:], ".") + "`"
+		}
+		return location
+	}
+
+	return "`" + strings.Join(tokens, ".") + "`"
+}
+
+func envListItemKind(listKey string) string {
+	switch listKey {
+	case "inputs":
+		return "input"
+	case "outputs":
+		return "output"
+	}
+	return "env"
+}
+
+func isIndex(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+// ordinal converts a 0-based pointer index token into a 1-based position.
+func ordinal(token string) string {
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return token
+	}
+	return strconv.Itoa(idx + 1)
+}
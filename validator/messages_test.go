@@ -0,0 +1,158 @@
+package validator
+
+import (
+	"sort"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestMessageRenderer_BitriseYML(t *testing.T) {
+	renderer := NewBitriseYMLMessageRenderer()
+
+	tests := []struct {
+		name        string
+		issue       ValidationIssue
+		wantText    string
+		wantHint    string
+		wantDocsURL string
+	}{
+		{
+			name: "unknown step list key",
+			issue: ValidationIssue{
+				InstancePtr: "#/workflows/test/steps/0",
+				SchemaPtr:   "#/definitions/WorkflowModel/properties/steps/items/additionalProperties",
+				Message:     `additionalProperties "with" not allowed`,
+				Keyword:     "additionalProperties",
+			},
+			wantText:    "workflow `test`, step 1: unknown key `with`",
+			wantHint:    "steps have to be listed under the steps key of the workflow",
+			wantDocsURL: bitriseWorkflowsDocsURL,
+		},
+		{
+			name: "unknown step keys",
+			issue: ValidationIssue{
+				InstancePtr: "#/workflows/test/steps/3/script@1",
+				SchemaPtr:   "#/definitions/StepModel/additionalProperties",
+				Message:     `additionalProperties "content", "is_debug" not allowed`,
+				Keyword:     "additionalProperties",
+			},
+			wantText:    "workflow `test`, step 4 (`script@1`): unknown keys `content`, `is_debug`",
+			wantHint:    "step inputs have to be listed under the inputs key of the step",
+			wantDocsURL: bitriseStepsDocsURL,
+		},
+		{
+			name: "with group step input type",
+			issue: ValidationIssue{
+				InstancePtr: "#/workflows/test/steps/1/with/steps/0/script/timeout",
+				SchemaPtr:   "#/definitions/StepModel/properties/timeout/type",
+				Message:     "expected integer, but got string",
+				Keyword:     "type",
+			},
+			wantText:    "workflow `test`, step 2 (`with` group), step 1 (`script`), `timeout`: expected an integer, but got a string",
+			wantDocsURL: bitriseStepsDocsURL,
+		},
+		{
+			name: "unknown key with suggestion",
+			issue: ValidationIssue{
+				InstancePtr: "#/workflows/test",
+				SchemaPtr:   "#/definitions/WorkflowModel/additionalProperties",
+				Message:     `additionalProperties "befor_run" not allowed`,
+				Keyword:     "additionalProperties",
+				Suggestions: []string{"before_run"},
+			},
+			wantText:    "workflow `test`: unknown key `befor_run`, did you mean `before_run`?",
+			wantHint:    "steps have to be listed under the steps key of the workflow",
+			wantDocsURL: bitriseWorkflowsDocsURL,
+		},
+		{
+			name: "stage workflow",
+			issue: ValidationIssue{
+				InstancePtr: "#/stages/build/workflows/1/missing",
+				SchemaPtr:   semanticSchemaPtr(KeywordUndefinedWorkflow),
+				Message:     `workflow "missing" is not defined`,
+				Keyword:     KeywordUndefinedWorkflow,
+			},
+			wantText:    "stage `build`, workflow `missing`: workflow \"missing\" is not defined",
+			wantHint:    "check the spelling of the workflow name",
+			wantDocsURL: bitriseWorkflowsDocsURL,
+		},
+		{
+			name: "trigger map item",
+			issue: ValidationIssue{
+				InstancePtr: "#/trigger_map/2/push_branch",
+				SchemaPtr:   "#/definitions/TriggerMapItemModel/properties/push_branch/oneOf",
+				Message:     "oneOf failed",
+				Keyword:     "oneOf",
+			},
+			wantText:    "trigger_map item 3, `push_branch`: oneOf failed",
+			wantHint:    "a trigger condition is either a string pattern or a {regex: ...} map",
+			wantDocsURL: bitriseTriggersDocsURL,
+		},
+		{
+			name: "root",
+			issue: ValidationIssue{
+				InstancePtr: "#",
+				SchemaPtr:   "#/definitions/BitriseDataModel/additionalProperties",
+				Message:     `additionalProperties "workflow" not allowed`,
+				Keyword:     "additionalProperties",
+			},
+			wantText:    "unknown key `workflow`",
+			wantHint:    "check the spelling of the top level keys, like workflows, pipelines or trigger_map",
+			wantDocsURL: bitriseYMLDocsURL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderer.Render(tt.issue)
+			if got.Text != tt.wantText {
+				t.Errorf("Text = %s, want %s", got.Text, tt.wantText)
+			}
+			if got.Hint != tt.wantHint {
+				t.Errorf("Hint = %s, want %s", got.Hint, tt.wantHint)
+			}
+			if got.DocsURL != tt.wantDocsURL {
+				t.Errorf("DocsURL = %s, want %s", got.DocsURL, tt.wantDocsURL)
+			}
+		})
+	}
+}
+
+func TestMessageRenderer_StepYML(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+	issues, err := v.ValidateIssues(`title: Script
+summary:
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+inputs:
+- content: ""
+  opts:
+    title: Script content
+`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+
+	renderer := NewStepYMLMessageRenderer()
+	var got []string
+	for _, issue := range issues {
+		got = append(got, renderer.Render(issue).String())
+	}
+	sort.Strings(got)
+	want := []string{
+		"`summary`: value is empty, expected a string\n  docs: " + bitriseStepDevelopDocsURL,
+		"input 1, `opts`: missing required key `summary`\n  hint: every input and output needs a title and a summary in its opts\n  docs: " + bitriseStepInputsDocsURL,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrMultiDocument is returned for multi-document YAML input if the validator was created WithMultiDocumentRejected.
+var ErrMultiDocument = errors.New("multi-document YAML input is not allowed")
+
+// WithMultiDocumentRejected makes the validator return ErrMultiDocument for YAML streams with more than one document,
+// instead of validating only the first (ValidateIssues) or each (ValidateDocuments) document.
+func WithMultiDocumentRejected() Option {
+	return func(v *JSONSchemaValidator) {
+		v.rejectMultiDocument = true
+	}
+}
+
+// ValidateDocuments validates each document of a `---` separated YAML stream.
+// The issues are tagged with the 0-based index of the document they were found in.
+func (v JSONSchemaValidator) ValidateDocuments(ymlStr string, warningPatterns ...string) ([]ValidationIssue, error) {
+	stream, err := ParseYAML(ymlStr)
+	if err != nil {
+		return nil, err
+	}
+	if v.rejectMultiDocument && len(stream.Documents) > 1 {
+		return nil, ErrMultiDocument
+	}
+
+	var issues []ValidationIssue
+	for idx, document := range stream.Documents {
+		documentIssues, err := v.validateDocument(document.Value, idx, keyIssues(document.Root), warningPatterns)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, documentIssues...)
+	}
+
+	locateIssuesInRoots(issues, stream.roots())
+
+	return issues, nil
+}
+
+// decodeYAMLDocuments decodes every document of the YAML stream into JSON compatible values.
+// An empty stream is decoded as a single empty document.
+func decodeYAMLDocuments(source []byte) ([]*YAMLDocument, error) {
+	var documents []*YAMLDocument
+	decoder := yaml.NewDecoder(bytes.NewReader(source))
+	for {
+		var m interface{}
+		if err := decoder.Decode(&m); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		documents = append(documents, &YAMLDocument{Value: recursiveJSONMarshallable(m)})
+	}
+
+	if len(documents) == 0 {
+		documents = append(documents, &YAMLDocument{})
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+const multiDocumentStepYML = `title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+---
+title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
+
+func TestJSONSchemaValidator_ValidateDocuments(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateDocuments(multiDocumentStepYML)
+	if err != nil {
+		t.Fatalf("ValidateDocuments() error = %v", err)
+	}
+	want := []ValidationIssue{{
+		InstancePtr: "#",
+		SchemaPtr:   "#/required",
+		Message:     `missing properties: "source_code_url"`,
+		Keyword:     "required",
+		Severity:    SeverityError,
+		Line:        7,
+		Column:      1,
+		Document:    1,
+	}}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateDocuments() got = %#v, want %#v", issues, want)
+	}
+
+	issues, err = v.ValidateIssues(multiDocumentStepYML)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("ValidateIssues() is expected to validate only the first document, got = %v", issues)
+	}
+}
+
+func TestJSONSchemaValidator_WithMultiDocumentRejected(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema, WithMultiDocumentRejected())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	if _, err := v.ValidateDocuments(multiDocumentStepYML); !errors.Is(err, ErrMultiDocument) {
+		t.Errorf("ValidateDocuments() error = %v, want %v", err, ErrMultiDocument)
+	}
+	if _, err := v.ValidateIssues(multiDocumentStepYML); !errors.Is(err, ErrMultiDocument) {
+		t.Errorf("ValidateIssues() error = %v, want %v", err, ErrMultiDocument)
+	}
+
+	issues, err := v.ValidateDocuments("---\n" + validStepYML)
+	if err != nil {
+		t.Fatalf("ValidateDocuments() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("ValidateDocuments() got = %v", issues)
+	}
+}
+
+const validStepYML = `title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+source_code_url: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
@@ -0,0 +1,190 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	KeywordUndefinedDependency = "undefinedDependency"
+	KeywordSelfDependency      = "selfDependency"
+	KeywordDependencyCycle     = "dependencyCycle"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordUndefinedDependency: "Pipeline workflow depending on a workflow outside of the pipeline",
+	KeywordSelfDependency:      "Pipeline workflow depending on itself",
+	KeywordDependencyCycle:     "Dependency cycle between pipeline workflows",
+})
+
+// PipelineGraph is the dependency graph of the workflows of a graph pipeline.
+type PipelineGraph struct {
+	Pipeline string
+	// Dependencies maps the workflows of the pipeline to the workflows they depend on, in the depends_on order.
+	// Dependencies missing from the pipeline and self-dependencies are left out.
+	Dependencies map[string][]string
+	// Levels are the workflows of the pipeline grouped by their topological level, in alphabetical order:
+	// the first level are the workflows without dependencies, and every other workflow is on the level following
+	// the one of its last finishing dependency. Workflows on a dependency cycle, or depending on one, are left out.
+	Levels [][]string
+	// Issues are the dependency issues of the pipeline, see CheckPipelineGraphs.
+	Issues []ValidationIssue
+}
+
+// AnalyzePipelines builds the dependency graph of the graph pipelines of the bitrise.yml, in alphabetical order.
+// Pipelines built of stages are left out.
+func AnalyzePipelines(document interface{}) []PipelineGraph {
+	model := LoadBitriseYML(document)
+
+	var graphs []PipelineGraph
+	for _, name := range model.PipelineNames() {
+		if len(model.Pipelines[name].Workflows) == 0 {
+			continue
+		}
+		graphs = append(graphs, analyzePipeline(model, name))
+	}
+	return graphs
+}
+
+// CheckPipelineGraphs reports the workflows of graph pipelines depending on workflows missing from the pipeline
+// or on themselves, the dependency cycles with the full chain of workflows, and the pipeline workflows running
+// undefined workflows.
+func CheckPipelineGraphs(document interface{}) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, graph := range AnalyzePipelines(document) {
+		issues = append(issues, graph.Issues...)
+	}
+	return issues
+}
+
+func analyzePipeline(model *BitriseYML, name string) PipelineGraph {
+	pipeline := model.Pipelines[name]
+	graph := PipelineGraph{Pipeline: name, Dependencies: map[string][]string{}}
+	// dependencyPtrs are the JSON pointers of the depends_on items of the graph edges.
+	dependencyPtrs := map[string]map[string]string{}
+
+	for _, workflowName := range pipeline.WorkflowNames() {
+		workflow := pipeline.Workflows[workflowName]
+		if workflow.Uses != nil {
+			if _, ok := model.Workflows[workflow.Uses.Name]; !ok {
+				graph.Issues = append(graph.Issues, undefinedReferenceIssue(*workflow.Uses, "workflow", KeywordUndefinedWorkflow))
+			}
+		} else if _, ok := model.Workflows[workflowName]; !ok {
+			graph.Issues = append(graph.Issues, undefinedReferenceIssue(Reference{Name: workflowName, Ptr: workflow.Ptr}, "workflow", KeywordUndefinedWorkflow))
+		}
+
+		graph.Dependencies[workflowName] = []string{}
+		dependencyPtrs[workflowName] = map[string]string{}
+		for _, dependency := range workflow.DependsOn {
+			switch _, ok := pipeline.Workflows[dependency.Name]; {
+			case dependency.Name == workflowName:
+				graph.Issues = append(graph.Issues, ValidationIssue{
+					InstancePtr: dependency.Ptr,
+					SchemaPtr:   semanticSchemaPtr(KeywordSelfDependency),
+					Message:     fmt.Sprintf("workflow %q depends on itself", workflowName),
+					Keyword:     KeywordSelfDependency,
+				})
+			case !ok:
+				graph.Issues = append(graph.Issues, ValidationIssue{
+					InstancePtr: dependency.Ptr,
+					SchemaPtr:   semanticSchemaPtr(KeywordUndefinedDependency),
+					Message:     fmt.Sprintf("depends_on workflow %q is not part of pipeline %q", dependency.Name, name),
+					Keyword:     KeywordUndefinedDependency,
+				})
+			default:
+				if _, ok := dependencyPtrs[workflowName][dependency.Name]; ok {
+					continue
+				}
+				graph.Dependencies[workflowName] = append(graph.Dependencies[workflowName], dependency.Name)
+				dependencyPtrs[workflowName][dependency.Name] = dependency.Ptr
+			}
+		}
+	}
+
+	for _, cycle := range dependencyCycles(pipeline.WorkflowNames(), graph.Dependencies) {
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		graph.Issues = append(graph.Issues, ValidationIssue{
+			InstancePtr: dependencyPtrs[from][to],
+			SchemaPtr:   semanticSchemaPtr(KeywordDependencyCycle),
+			Message:     fmt.Sprintf("dependency cycle: %s", strings.Join(cycle, " -> ")),
+			Keyword:     KeywordDependencyCycle,
+		})
+	}
+	graph.Levels = topologicalLevels(pipeline.WorkflowNames(), graph.Dependencies)
+
+	return graph
+}
+
+// dependencyCycles returns the cycles of the dependency graph found by a depth-first search over the nodes
+// in the given order. A cycle is the chain of nodes from its first node back to the first node.
+func dependencyCycles(nodes []string, dependencies map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(node string)
+	visit = func(node string) {
+		state[node] = visiting
+		stack = append(stack, node)
+		for _, dependency := range dependencies[node] {
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case visiting:
+				for idx := len(stack) - 1; idx >= 0; idx-- {
+					if stack[idx] == dependency {
+						cycles = append(cycles, append(append([]string{}, stack[idx:]...), dependency))
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+	return cycles
+}
+
+// topologicalLevels groups the nodes by their topological level, see PipelineGraph.Levels.
+func topologicalLevels(nodes []string, dependencies map[string][]string) [][]string {
+	placed := map[string]bool{}
+	var levels [][]string
+	for {
+		var level []string
+		for _, node := range nodes {
+			if placed[node] {
+				continue
+			}
+			ready := true
+			for _, dependency := range dependencies[node] {
+				if !placed[dependency] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, node)
+			}
+		}
+		if len(level) == 0 {
+			return levels
+		}
+		sort.Strings(level)
+		for _, node := range level {
+			placed[node] = true
+		}
+		levels = append(levels, level)
+	}
+}
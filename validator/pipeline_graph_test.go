@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+const bitriseYMLWithGraphPipelines = `format_version: "13"
+pipelines:
+  staged:
+    stages:
+    - build: {}
+  graph:
+    workflows:
+      build: {}
+      unit_test:
+        depends_on: [build]
+      ui_test:
+        depends_on: [build]
+        parallel: 2
+      deploy:
+        depends_on: [unit_test, ui_test]
+        uses: release
+  broken:
+    workflows:
+      build:
+        depends_on: [build, lint]
+      a:
+        uses: test
+        depends_on: [c]
+      b:
+        uses: test
+        depends_on: [a]
+      c:
+        uses: test
+        depends_on: [b]
+      d:
+        uses: missing
+        depends_on: [a, build]
+stages:
+  build:
+    workflows:
+    - build: {}
+workflows:
+  build: {}
+  unit_test: {}
+  ui_test: {}
+  release: {}
+  test: {}
+`
+
+func TestAnalyzePipelines(t *testing.T) {
+	graphs := AnalyzePipelines(decodeTestYAML(t, bitriseYMLWithGraphPipelines))
+	if len(graphs) != 2 {
+		t.Fatalf("AnalyzePipelines() returned %d graphs, want 2", len(graphs))
+	}
+
+	broken, graph := graphs[0], graphs[1]
+	if graph.Pipeline != "graph" || len(graph.Issues) != 0 {
+		t.Errorf("AnalyzePipelines() graph = %s with issues %v, want graph without issues", graph.Pipeline, graph.Issues)
+	}
+	wantLevels := [][]string{{"build"}, {"ui_test", "unit_test"}, {"deploy"}}
+	if !reflect.DeepEqual(graph.Levels, wantLevels) {
+		t.Errorf("AnalyzePipelines() levels = %v, want %v", graph.Levels, wantLevels)
+	}
+	wantDependencies := map[string][]string{
+		"build":     {},
+		"unit_test": {"build"},
+		"ui_test":   {"build"},
+		"deploy":    {"unit_test", "ui_test"},
+	}
+	if !reflect.DeepEqual(graph.Dependencies, wantDependencies) {
+		t.Errorf("AnalyzePipelines() dependencies = %v, want %v", graph.Dependencies, wantDependencies)
+	}
+
+	wantIssues := []ValidationIssue{
+		{InstancePtr: "#/pipelines/broken/workflows/build/depends_on/0", SchemaPtr: semanticSchemaPtr(KeywordSelfDependency), Message: `workflow "build" depends on itself`, Keyword: KeywordSelfDependency},
+		{InstancePtr: "#/pipelines/broken/workflows/build/depends_on/1", SchemaPtr: semanticSchemaPtr(KeywordUndefinedDependency), Message: `depends_on workflow "lint" is not part of pipeline "broken"`, Keyword: KeywordUndefinedDependency},
+		{InstancePtr: "#/pipelines/broken/workflows/d/uses", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `workflow "missing" is not defined`, Keyword: KeywordUndefinedWorkflow},
+		{InstancePtr: "#/pipelines/broken/workflows/b/depends_on/0", SchemaPtr: semanticSchemaPtr(KeywordDependencyCycle), Message: "dependency cycle: a -> c -> b -> a", Keyword: KeywordDependencyCycle},
+	}
+	if !reflect.DeepEqual(broken.Issues, wantIssues) {
+		t.Errorf("AnalyzePipelines() broken issues =\n%#v\nwant\n%#v", broken.Issues, wantIssues)
+	}
+	if wantLevels := [][]string{{"build"}}; !reflect.DeepEqual(broken.Levels, wantLevels) {
+		t.Errorf("AnalyzePipelines() broken levels = %v, want %v", broken.Levels, wantLevels)
+	}
+}
+
+func TestCheckPipelineGraphs_UndefinedWorkflow(t *testing.T) {
+	issues := CheckPipelineGraphs(decodeTestYAML(t, `pipelines:
+  ci:
+    workflows:
+      test: {}
+`))
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/pipelines/ci/workflows/test", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `workflow "test" is not defined`, Keyword: KeywordUndefinedWorkflow},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckPipelineGraphs() got = %#v, want %#v", issues, want)
+	}
+}
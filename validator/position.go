@@ -0,0 +1,188 @@
+package validator
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// parseNodeTrees returns the root node of each document in the YAML stream.
+func parseNodeTrees(source []byte) ([]*yamlv3.Node, error) {
+	documents, err := parseDocumentNodes(source)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]*yamlv3.Node, 0, len(documents))
+	for _, document := range documents {
+		roots = append(roots, documentRoot(document))
+	}
+	return roots, nil
+}
+
+// parseDocumentNodes returns the document node of each document in the YAML stream.
+func parseDocumentNodes(source []byte) ([]*yamlv3.Node, error) {
+	var documents []*yamlv3.Node
+	decoder := yamlv3.NewDecoder(bytes.NewReader(source))
+	for {
+		var document yamlv3.Node
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				return documents, nil
+			}
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+}
+
+type sourcePosition struct {
+	line, column int
+}
+
+// sourceSites are the positions of a node referenced by a JSON pointer.
+type sourceSites struct {
+	// use is the position of the node, or if the pointer resolves through an alias or a merge key,
+	// the position of the outermost alias.
+	use sourcePosition
+	// definition is the position of the node within the anchored node the alias refers to,
+	// it is nil if the pointer doesn't resolve through an alias.
+	definition *sourcePosition
+}
+
+// locateSites returns the positions of the YAML node referenced by the given JSON pointer.
+// For mapping entries the position of the key is used, for sequence items the position of the item.
+// If the pointer can't be fully resolved, the position of the deepest resolved node is used.
+//
+// Aliases and merge keys are followed explicitly.
+// Merge keys are resolved as YAML does: the keys of the mapping override the merged ones,
+// and the earlier merged mappings override the later ones.
+func locateSites(root *yamlv3.Node, instancePtr string) sourceSites {
+	if root == nil {
+		return sourceSites{}
+	}
+
+	var use *sourcePosition
+	crossAlias := func(alias *yamlv3.Node) {
+		if use == nil {
+			use = &sourcePosition{line: alias.Line, column: alias.Column}
+		}
+	}
+
+	node := root
+	position := sourcePosition{line: node.Line, column: node.Column}
+	for _, token := range splitPtr(instancePtr) {
+		for node.Kind == yamlv3.AliasNode && node.Alias != nil {
+			crossAlias(node)
+			node = node.Alias
+		}
+
+		resolved := false
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			key, value, via := mergedMappingEntry(node, token)
+			if key == nil {
+				break
+			}
+			if via != nil {
+				crossAlias(via)
+			}
+			position = sourcePosition{line: key.Line, column: key.Column}
+			node, resolved = value, true
+		case yamlv3.SequenceNode:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				break
+			}
+			node = node.Content[idx]
+			position = sourcePosition{line: node.Line, column: node.Column}
+			resolved = true
+		}
+		if !resolved {
+			break
+		}
+	}
+	if node.Kind == yamlv3.AliasNode && node.Alias != nil && use == nil {
+		// The referenced value itself is an alias: it is used here and defined at its anchor.
+		use = &sourcePosition{line: position.line, column: position.column}
+		position = sourcePosition{line: node.Alias.Line, column: node.Alias.Column}
+	}
+
+	if use == nil {
+		return sourceSites{use: position}
+	}
+	return sourceSites{use: *use, definition: &position}
+}
+
+// mergedMappingEntry returns the entry of the mapping with the given key, looking into the merged mappings too.
+// via is the alias of the merged mapping the entry was found in, it is nil for entries of the mapping itself
+// and of inline merged mappings.
+func mergedMappingEntry(mapping *yamlv3.Node, key string) (keyNode, valueNode, via *yamlv3.Node) {
+	if keyNode, valueNode = mappingEntry(mapping, key); keyNode != nil && keyNode.Value != mergeKey {
+		return keyNode, valueNode, nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].ShortTag() != "!!merge" {
+			continue
+		}
+		sources := []*yamlv3.Node{mapping.Content[i+1]}
+		if sources[0].Kind == yamlv3.SequenceNode {
+			sources = sources[0].Content
+		}
+		for _, source := range sources {
+			target, alias := source, (*yamlv3.Node)(nil)
+			for target.Kind == yamlv3.AliasNode && target.Alias != nil {
+				if alias == nil {
+					alias = target
+				}
+				target = target.Alias
+			}
+			if target.Kind != yamlv3.MappingNode {
+				continue
+			}
+			if keyNode, valueNode, nested := mergedMappingEntry(target, key); keyNode != nil {
+				if alias == nil {
+					alias = nested
+				}
+				return keyNode, valueNode, alias
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// mappingEntry returns the last entry of the mapping with the given key, as the last one wins when decoding.
+func mappingEntry(mapping *yamlv3.Node, key string) (*yamlv3.Node, *yamlv3.Node) {
+	if mapping == nil || mapping.Kind != yamlv3.MappingNode {
+		return nil, nil
+	}
+	for i := len(mapping.Content) - 2; i >= 0; i -= 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// splitPtr splits a JSON pointer, like #/workflows/test/steps/3, into its unescaped reference tokens.
+func splitPtr(ptr string) []string {
+	ptr = strings.TrimPrefix(ptr, "#")
+	ptr = strings.TrimPrefix(ptr, "/")
+	if ptr == "" {
+		return nil
+	}
+
+	tokens := strings.Split(ptr, "/")
+	for i, token := range tokens {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens
+}
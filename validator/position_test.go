@@ -0,0 +1,165 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func Test_locateSites(t *testing.T) {
+	ymlStr := `format_version: "13"
+workflows:
+  test:
+    steps:
+    - script:
+        inputs:
+        - content: echo "hello"
+  a/b:
+    title: "slash"
+`
+	roots, err := parseNodeTrees([]byte(ymlStr))
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("parseNodeTrees() = %d roots, error = %v", len(roots), err)
+	}
+	root := roots[0]
+
+	tests := []struct {
+		instancePtr string
+		wantLine    int
+		wantColumn  int
+	}{
+		{instancePtr: "#", wantLine: 1, wantColumn: 1},
+		{instancePtr: "#/format_version", wantLine: 1, wantColumn: 1},
+		{instancePtr: "#/workflows/test/steps/0", wantLine: 5, wantColumn: 7},
+		{instancePtr: "#/workflows/test/steps/0/script/inputs/0/content", wantLine: 7, wantColumn: 11},
+		{instancePtr: "#/workflows/a~1b/title", wantLine: 9, wantColumn: 5},
+		{instancePtr: "#/workflows/missing/steps", wantLine: 2, wantColumn: 1},
+		{instancePtr: "#/workflows/test/steps/7", wantLine: 4, wantColumn: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instancePtr, func(t *testing.T) {
+			sites := locateSites(root, tt.instancePtr)
+			if sites.use.line != tt.wantLine || sites.use.column != tt.wantColumn || sites.definition != nil {
+				t.Errorf("locateSites() = %+v, want %d:%d", sites, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func Test_locateSites_Aliases(t *testing.T) {
+	ymlStr := `format_version: "13"
+step_defaults: &step_defaults
+  is_always_run: "yes"
+  inputs: &inputs
+  - content: echo "hello"
+workflows:
+  a:
+    steps:
+    - script:
+        <<: *step_defaults
+        title: Script
+  b:
+    steps:
+    - script:
+        inputs: *inputs
+  c:
+    steps:
+    - script:
+        <<: [{timeout: 1}, *step_defaults]
+`
+	roots, err := parseNodeTrees([]byte(ymlStr))
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("parseNodeTrees() = %d roots, error = %v", len(roots), err)
+	}
+	root := roots[0]
+
+	tests := []struct {
+		instancePtr    string
+		wantUse        sourcePosition
+		wantDefinition *sourcePosition
+	}{
+		{instancePtr: "#/workflows/a/steps/0/script/title", wantUse: sourcePosition{line: 11, column: 9}},
+		{instancePtr: "#/workflows/a/steps/0/script/is_always_run", wantUse: sourcePosition{line: 10, column: 13}, wantDefinition: &sourcePosition{line: 3, column: 3}},
+		{instancePtr: "#/workflows/a/steps/0/script/inputs/0/content", wantUse: sourcePosition{line: 10, column: 13}, wantDefinition: &sourcePosition{line: 5, column: 5}},
+		{instancePtr: "#/workflows/b/steps/0/script/inputs", wantUse: sourcePosition{line: 15, column: 9}, wantDefinition: &sourcePosition{line: 4, column: 11}},
+		{instancePtr: "#/workflows/b/steps/0/script/inputs/0", wantUse: sourcePosition{line: 15, column: 17}, wantDefinition: &sourcePosition{line: 5, column: 5}},
+		{instancePtr: "#/workflows/c/steps/0/script/timeout", wantUse: sourcePosition{line: 19, column: 15}},
+		{instancePtr: "#/workflows/c/steps/0/script/is_always_run", wantUse: sourcePosition{line: 19, column: 28}, wantDefinition: &sourcePosition{line: 3, column: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instancePtr, func(t *testing.T) {
+			sites := locateSites(root, tt.instancePtr)
+			if sites.use != tt.wantUse {
+				t.Errorf("use = %+v, want %+v", sites.use, tt.wantUse)
+			}
+			if (sites.definition == nil) != (tt.wantDefinition == nil) || sites.definition != nil && *sites.definition != *tt.wantDefinition {
+				t.Errorf("definition = %+v, want %+v", sites.definition, tt.wantDefinition)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidator_MergedValuePositions(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateIssues(validStepYML + `inputs:
+- first: ""
+  opts: &opts
+    title: First
+    summary: First input
+    is_required: "yes"
+- second: ""
+  opts:
+    <<: *opts
+    title: Second
+`)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("ValidateIssues() = %v, want 2 issues", issues)
+	}
+
+	for _, issue := range issues {
+		switch issue.InstancePtr {
+		case "#/inputs/0/opts/is_required":
+			if issue.Line != 11 || issue.Column != 5 || issue.DefinitionLine != 0 {
+				t.Errorf("unexpected position of the anchored value: %+v", issue)
+			}
+		case "#/inputs/1/opts/is_required":
+			if issue.Line != 14 || issue.Column != 9 || issue.DefinitionLine != 11 || issue.DefinitionColumn != 5 {
+				t.Errorf("unexpected position of the merged value: %+v", issue)
+			}
+		default:
+			t.Errorf("unexpected issue: %s", issue)
+		}
+	}
+}
+
+func TestJSONSchemaValidator_UnparsableNodeTree(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	// yaml.v2 decodes the first document only, yaml.v3 fails on the broken second one.
+	issues, err := v.ValidateIssues(validStepYML + "description: 1\n---\n[broken\n")
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	want := []string{"I[#/description] S[#/properties/description/type] expected string, but got number"}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+		if issue.Line != 0 {
+			t.Errorf("unexpected position of the issue: %+v", issue)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateIssues() got = %v, want %v", got, want)
+	}
+}
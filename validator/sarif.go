@@ -0,0 +1,208 @@
+package validator
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	sarifToolName           = "bitrise-json-schemas"
+	sarifToolInformationURI = "https://github.com/bitrise-io/bitrise-json-schemas"
+
+	// sarifDefaultRuleID is used for issues without a schema keyword.
+	sarifDefaultRuleID = "schema"
+)
+
+// sarifRuleDescriptions are the short descriptions of the rules of the issues the JSON schema can't express.
+// The files defining these keywords register them with describeRules.
+var sarifRuleDescriptions = map[string]string{}
+
+// describeRules registers the SARIF rule descriptions of keywords the JSON schema doesn't have.
+// It returns true, so that it can initialize a package level variable next to the keywords.
+func describeRules(descriptions map[string]string) bool {
+	for keyword, description := range descriptions {
+		sarifRuleDescriptions[keyword] = description
+	}
+	return true
+}
+
+// SARIFReporter collects validation issues of one or more files and renders them as a SARIF 2.1.0 log.
+// Each schema keyword becomes a rule and each issue a result.
+type SARIFReporter struct {
+	rules     []SARIFRule
+	ruleIndex map[string]int
+	results   []SARIFResult
+}
+
+func NewSARIFReporter() *SARIFReporter {
+	return &SARIFReporter{ruleIndex: map[string]int{}}
+}
+
+// Add records the issues found in the file identified by the given URI.
+func (r *SARIFReporter) Add(uri string, issues []ValidationIssue) {
+	for _, issue := range issues {
+		ruleID := issue.Keyword
+		if ruleID == "" {
+			ruleID = sarifDefaultRuleID
+		}
+		idx, ok := r.ruleIndex[ruleID]
+		if !ok {
+			idx = len(r.rules)
+			r.ruleIndex[ruleID] = idx
+			r.rules = append(r.rules, SARIFRule{
+				ID:               ruleID,
+				ShortDescription: SARIFMessage{Text: sarifRuleDescription(ruleID, issue.SchemaPtr)},
+			})
+		}
+
+		location := SARIFLocation{
+			PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: uri}},
+			LogicalLocations: []SARIFLogicalLocation{{FullyQualifiedName: issue.InstancePtr}},
+		}
+		if issue.Line > 0 {
+			location.PhysicalLocation.Region = &SARIFRegion{StartLine: issue.Line, StartColumn: issue.Column}
+		}
+
+		var relatedLocations []SARIFLocation
+		if issue.DefinitionLine > 0 {
+			relatedLocations = append(relatedLocations, SARIFLocation{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{URI: uri},
+					Region:           &SARIFRegion{StartLine: issue.DefinitionLine, StartColumn: issue.DefinitionColumn},
+				},
+				Message: &SARIFMessage{Text: "defined under a YAML anchor here"},
+			})
+		}
+
+		r.results = append(r.results, SARIFResult{
+			RuleID:           ruleID,
+			RuleIndex:        idx,
+			Level:            sarifLevel(issue.Severity),
+			Message:          SARIFMessage{Text: issue.Message},
+			Locations:        []SARIFLocation{location},
+			RelatedLocations: relatedLocations,
+			Properties: map[string]string{
+				"instancePointer": issue.InstancePtr,
+				"schemaPointer":   issue.SchemaPtr,
+			},
+		})
+	}
+}
+
+// Log returns the SARIF log of the recorded issues.
+func (r *SARIFReporter) Log() SARIFLog {
+	results := r.results
+	if results == nil {
+		results = []SARIFResult{}
+	}
+	rules := r.rules
+	if rules == nil {
+		rules = []SARIFRule{}
+	}
+
+	return SARIFLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           sarifToolName,
+				InformationURI: sarifToolInformationURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// Write writes the SARIF log of the recorded issues as indented JSON.
+func (r *SARIFReporter) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.Log())
+}
+
+// sarifRuleDescription describes the rule of the keyword, the keywords of custom semantic checks are described generically.
+func sarifRuleDescription(ruleID, schemaPtr string) string {
+	if description, ok := sarifRuleDescriptions[ruleID]; ok {
+		return description
+	}
+	if strings.HasPrefix(schemaPtr, semanticSchemaPtrPrefix) {
+		return "Semantic check: " + ruleID
+	}
+	return "JSON schema keyword: " + ruleID
+}
+
+func sarifLevel(severity Severity) string {
+	if severity == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+type SARIFResult struct {
+	RuleID           string            `json:"ruleId"`
+	RuleIndex        int               `json:"ruleIndex"`
+	Level            string            `json:"level"`
+	Message          SARIFMessage      `json:"message"`
+	Locations        []SARIFLocation   `json:"locations"`
+	RelatedLocations []SARIFLocation   `json:"relatedLocations,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *SARIFMessage          `json:"message,omitempty"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type SARIFLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSARIFReporter(t *testing.T) {
+	reporter := NewSARIFReporter()
+	reporter.Add("step.yml", []ValidationIssue{
+		{InstancePtr: "#", SchemaPtr: "#/required", Message: `missing properties: "source_code_url"`, Keyword: "required", Severity: SeverityWarning, Line: 1, Column: 1},
+		{InstancePtr: "#/title", SchemaPtr: "#/properties/title/type", Message: "expected string, but got null", Keyword: "type", Severity: SeverityError, Line: 2, Column: 1, DefinitionLine: 7, DefinitionColumn: 3},
+	})
+	reporter.Add("other/step.yml", []ValidationIssue{
+		{InstancePtr: "#/summary", SchemaPtr: "#/properties/summary/type", Message: "expected string, but got null", Keyword: "type", Severity: SeverityError},
+	})
+
+	log := reporter.Log()
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %#v", log)
+	}
+
+	run := log.Runs[0]
+	wantRules := []SARIFRule{
+		{ID: "required", ShortDescription: SARIFMessage{Text: "JSON schema keyword: required"}},
+		{ID: "type", ShortDescription: SARIFMessage{Text: "JSON schema keyword: type"}},
+	}
+	if !reflect.DeepEqual(run.Tool.Driver.Rules, wantRules) {
+		t.Errorf("rules = %#v, want %#v", run.Tool.Driver.Rules, wantRules)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(run.Results))
+	}
+	first := run.Results[0]
+	if first.RuleID != "required" || first.RuleIndex != 0 || first.Level != "warning" {
+		t.Errorf("unexpected first result: %#v", first)
+	}
+	if region := first.Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 1 || region.StartColumn != 1 {
+		t.Errorf("unexpected first result region: %#v", region)
+	}
+	if len(first.RelatedLocations) != 0 {
+		t.Errorf("unexpected first result related locations: %#v", first.RelatedLocations)
+	}
+	if related := run.Results[1].RelatedLocations; len(related) != 1 || related[0].PhysicalLocation.Region.StartLine != 7 {
+		t.Errorf("unexpected second result related locations: %#v", related)
+	}
+	last := run.Results[2]
+	if last.RuleIndex != 1 || last.Level != "error" || last.Locations[0].PhysicalLocation.ArtifactLocation.URI != "other/step.yml" {
+		t.Errorf("unexpected last result: %#v", last)
+	}
+	if last.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("expected no region for an issue without location")
+	}
+
+	var buf bytes.Buffer
+	if err := reporter.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Write() produced invalid JSON: %v", err)
+	}
+	if decoded["$schema"] != "https://json.schemastore.org/sarif-2.1.0.json" {
+		t.Errorf("unexpected $schema: %v", decoded["$schema"])
+	}
+}
+
+func TestSARIFReporter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewSARIFReporter().Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("expected empty results array, got: %s", buf.String())
+	}
+}
+
+func TestSARIFReporter_SemanticRules(t *testing.T) {
+	reporter := NewSARIFReporter()
+	reporter.Add("bitrise.yml", []ValidationIssue{
+		undefinedReferenceIssue(Reference{Name: "deploy", Ptr: "#/trigger_map/0/workflow"}, "workflow", KeywordUndefinedWorkflow),
+		{InstancePtr: "#/app", SchemaPtr: semanticSchemaPtr("customCheck"), Message: "custom issue", Keyword: "customCheck"},
+	})
+
+	wantRules := []SARIFRule{
+		{ID: KeywordUndefinedWorkflow, ShortDescription: SARIFMessage{Text: "Reference to an undefined workflow"}},
+		{ID: "customCheck", ShortDescription: SARIFMessage{Text: "Semantic check: customCheck"}},
+	}
+	if rules := reporter.Log().Runs[0].Tool.Driver.Rules; !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("rules = %#v, want %#v", rules, wantRules)
+	}
+}
@@ -0,0 +1,442 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v3"
+)
+
+// SchemaCompileError is returned by NewJSONSchemaValidator if the schema can't be compiled.
+type SchemaCompileError struct {
+	// SchemaPtr is the JSON pointer of the failing part of the schema,
+	// like #/definitions/WithModel/properties/steps/items/patternProperties. It is empty if unknown.
+	SchemaPtr string
+	// Reason explains why the schema is invalid.
+	Reason string
+	Err    error
+}
+
+func (e *SchemaCompileError) Error() string {
+	if e.SchemaPtr == "" {
+		return fmt.Sprintf("schema compilation failed: %s", e.Reason)
+	}
+	return fmt.Sprintf("schema compilation failed at %s: %s", e.SchemaPtr, e.Reason)
+}
+
+func (e *SchemaCompileError) Unwrap() error {
+	return e.Err
+}
+
+// compileSchema compiles the schema, it never panics: panics of the schema compiler are returned as errors.
+func compileSchema(schemaStr string) (schema *jsonschema.Schema, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			schema = nil
+			err = &SchemaCompileError{Reason: fmt.Sprintf("unexpected schema compiler failure: %v", r)}
+		}
+	}()
+
+	schemaStr, err = prepareSchema(schemaStr)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Extensions[ecmaRegexExtensionName] = ecmaRegexExtension()
+	if err := compiler.AddResource("schema.json", strings.NewReader(schemaStr)); err != nil {
+		return nil, &SchemaCompileError{Reason: err.Error(), Err: err}
+	}
+	schema, err = compiler.Compile("schema.json")
+	if err != nil {
+		return nil, toSchemaCompileError(err)
+	}
+
+	return schema, nil
+}
+
+// prepareSchema checks the local references of the schema and rewrites it for the ECMA-262 regex extension.
+func prepareSchema(schemaStr string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(schemaStr))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := offsetPosition(schemaStr, syntaxErr.Offset)
+			return "", &SchemaCompileError{Reason: fmt.Sprintf("invalid JSON at line %d, column %d: %s", line, column, err), Err: err}
+		}
+		return "", &SchemaCompileError{Reason: fmt.Sprintf("invalid JSON: %s", err), Err: err}
+	}
+
+	refs := newSchemaRefs(doc)
+	if err := checkSchemaRefs(refs, doc, "#"); err != nil {
+		return "", err
+	}
+	if err := checkSameInstanceCycles(refs); err != nil {
+		return "", err
+	}
+	if err := rewriteSchemaRegexKeywords(doc, "#"); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", &SchemaCompileError{Reason: err.Error(), Err: err}
+	}
+	return buf.String(), nil
+}
+
+// schemaRefs resolves the local $ref values of a schema: JSON pointer fragments, like #/definitions/A,
+// and plain-name fragments, like #foo, referring to the subschema with the {"$id": "#foo"} anchor.
+type schemaRefs struct {
+	root    interface{}
+	anchors map[string]schemaAnchor
+}
+
+// schemaAnchor is a subschema with a plain-name fragment $id. moved is set if rewriteSchemaRegexKeywords moves it
+// under an ECMA-262 extension keyword, where the schema compiler doesn't look for $id values.
+type schemaAnchor struct {
+	ptr   string
+	moved bool
+}
+
+func newSchemaRefs(root interface{}) schemaRefs {
+	refs := schemaRefs{root: root, anchors: map[string]schemaAnchor{}}
+	refs.collectAnchors(root, "#", false)
+	return refs
+}
+
+// collectAnchors collects the plain-name fragment $id values of the subschemas the way the schema compiler does.
+// Subschemas with any other $id change the base URI of their $ref values, they are left out.
+func (r schemaRefs) collectAnchors(value interface{}, ptr string, moved bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if id, ok := m["$id"].(string); ok && ptr != "#" {
+		if !strings.HasPrefix(id, "#") {
+			return
+		}
+		if len(id) > 1 {
+			r.anchors[id] = schemaAnchor{ptr: ptr, moved: moved}
+		}
+	}
+
+	// The ECMA-262 rewrite moves additionalProperties too, if the schema has patternProperties.
+	_, hasPatternProperties := m["patternProperties"]
+	for _, keyword := range []string{"not", "additionalProperties", "additionalItems", "propertyNames", "contains", "if", "then", "else"} {
+		r.collectAnchors(m[keyword], joinPtr(ptr, keyword), moved || keyword == "additionalProperties" && hasPatternProperties)
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf", "items"} {
+		for idx, item := range asSlice(m[keyword]) {
+			r.collectAnchors(item, joinPtr(joinPtr(ptr, keyword), strconv.Itoa(idx)), moved)
+		}
+	}
+	r.collectAnchors(m["items"], joinPtr(ptr, "items"), moved)
+	for _, keyword := range []string{"definitions", "properties", "patternProperties", "dependencies"} {
+		subschemas := asMap(m[keyword])
+		for _, name := range sortedKeys(subschemas) {
+			r.collectAnchors(subschemas[name], joinPtr(joinPtr(ptr, keyword), name), moved || keyword == "patternProperties")
+		}
+	}
+}
+
+// target returns the pointer of the schema the local $ref refers to, or a SchemaCompileError reason
+// if the schema can't be found.
+func (r schemaRefs) target(ref string) (string, string) {
+	if ref == "#" || strings.HasPrefix(ref, "#/") {
+		if _, ok := resolveLocalPtr(r.root, ref); !ok {
+			return "", fmt.Sprintf("$ref %q points to a missing schema", ref)
+		}
+		if ref == "#/" {
+			return "#", ""
+		}
+		return ref, ""
+	}
+
+	anchor, ok := r.anchors[ref]
+	if !ok {
+		return "", fmt.Sprintf("$ref %q points to a missing schema", ref)
+	}
+	if anchor.moved {
+		return "", fmt.Sprintf("$ref %q points to the $id of a patternProperties or additionalProperties schema at %s, "+
+			"which can't be referred to by its $id, as these keywords are evaluated with ECMA-262 regex semantics", ref, anchor.ptr)
+	}
+	return anchor.ptr, ""
+}
+
+// checkSchemaRefs reports local $ref values pointing to missing schemas and $ref chains referring back to themselves.
+// Cycles through the other applicators are reported by checkSameInstanceCycles.
+func checkSchemaRefs(refs schemaRefs, value interface{}, ptr string) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			if err := checkSchemaRef(refs, ref, joinPtr(ptr, "$ref")); err != nil {
+				return err
+			}
+		}
+		for _, key := range sortedKeys(value) {
+			if key == "enum" || key == "const" || key == "default" || key == "examples" {
+				continue
+			}
+			if err := checkSchemaRefs(refs, value[key], joinPtr(ptr, key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for idx, item := range value {
+			if err := checkSchemaRefs(refs, item, joinPtr(ptr, strconv.Itoa(idx))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkSchemaRef(refs schemaRefs, ref, ptr string) error {
+	visited := map[string]bool{}
+	chain := []string{ref}
+	for {
+		targetPtr, reason := refs.target(ref)
+		if reason != "" {
+			return &SchemaCompileError{SchemaPtr: ptr, Reason: reason}
+		}
+		if visited[targetPtr] {
+			return &SchemaCompileError{SchemaPtr: ptr, Reason: fmt.Sprintf("circular $ref: %s", strings.Join(chain, " -> "))}
+		}
+		visited[targetPtr] = true
+
+		target, _ := resolveLocalPtr(refs.root, targetPtr)
+		targetMap, ok := target.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		next, ok := targetMap["$ref"].(string)
+		if !ok || !strings.HasPrefix(next, "#") {
+			return nil
+		}
+		ref = next
+		chain = append(chain, ref)
+	}
+}
+
+// checkSameInstanceCycles reports the schemas referring back to themselves through the keywords applying
+// a subschema to the same instance, like {"not": {"$ref": "#"}}. Validating against such a schema would recurse
+// endlessly, and the stack overflow would crash the process.
+func checkSameInstanceCycles(refs schemaRefs) error {
+	var refSchemas []string
+	collectRefSchemas(refs.root, "#", &refSchemas)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	// stack is the path of the walk, edges[i] is the keyword leading from stack[i] to the next schema.
+	var stack []string
+	var edges []subschemaEdge
+	var visit func(ptr string) error
+	visit = func(ptr string) error {
+		state[ptr] = visiting
+		stack = append(stack, ptr)
+		for _, edge := range sameInstanceSubschemas(refs, ptr) {
+			switch state[edge.target] {
+			case unvisited:
+				edges = append(edges, edge)
+				if err := visit(edge.target); err != nil {
+					return err
+				}
+				edges = edges[:len(edges)-1]
+			case visiting:
+				idx := indexOfString(stack, edge.target)
+				return sameInstanceCycleError(stack[idx:], append(append([]subschemaEdge{}, edges[idx:]...), edge))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[ptr] = visited
+		return nil
+	}
+
+	for _, ptr := range refSchemas {
+		if state[ptr] == unvisited {
+			if err := visit(ptr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sameInstanceCycleError reports the cycle at its last $ref, edges[i] leads from schemas[i] to the next schema of the cycle.
+// The chain starts at the target of the reported $ref.
+func sameInstanceCycleError(schemas []string, edges []subschemaEdge) error {
+	last := len(edges) - 1
+	for last > 0 && !strings.HasSuffix(edges[last].ptr, "/$ref") {
+		last--
+	}
+
+	var chain []string
+	for idx := range schemas {
+		chain = append(chain, edges[(last+idx)%len(edges)].target)
+	}
+	chain = append(chain, chain[0])
+	return &SchemaCompileError{
+		SchemaPtr: edges[last].ptr,
+		Reason:    fmt.Sprintf("schema applies itself to the same instance: %s", strings.Join(chain, " -> ")),
+	}
+}
+
+// collectRefSchemas collects the pointers of the schemas with a local $ref, as every cycle goes through one of them.
+func collectRefSchemas(value interface{}, ptr string, ptrs *[]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			*ptrs = append(*ptrs, ptr)
+		}
+		for _, key := range sortedKeys(value) {
+			if key == "enum" || key == "const" || key == "default" || key == "examples" {
+				continue
+			}
+			collectRefSchemas(value[key], joinPtr(ptr, key), ptrs)
+		}
+	case []interface{}:
+		for idx, item := range value {
+			collectRefSchemas(item, joinPtr(ptr, strconv.Itoa(idx)), ptrs)
+		}
+	}
+}
+
+// subschemaEdge is a keyword of a schema applying the target schema, ptr is the pointer of the keyword.
+type subschemaEdge struct {
+	ptr    string
+	target string
+}
+
+// sameInstanceSubschemas returns the subschemas the schema applies to the same instance it validates:
+// the target of its local $ref, and the schemas of its allOf, anyOf, oneOf, not, if, then, else
+// and dependencies keywords.
+func sameInstanceSubschemas(refs schemaRefs, ptr string) []subschemaEdge {
+	schema, ok := resolveLocalPtr(refs.root, ptr)
+	if !ok {
+		return nil
+	}
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var edges []subschemaEdge
+	if ref, ok := m["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		if target, reason := refs.target(ref); reason == "" {
+			edges = append(edges, subschemaEdge{ptr: joinPtr(ptr, "$ref"), target: target})
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		for idx := range asSlice(m[keyword]) {
+			itemPtr := joinPtr(joinPtr(ptr, keyword), strconv.Itoa(idx))
+			edges = append(edges, subschemaEdge{ptr: itemPtr, target: itemPtr})
+		}
+	}
+	for _, keyword := range []string{"not", "if", "then", "else"} {
+		if _, ok := m[keyword].(map[string]interface{}); ok {
+			edges = append(edges, subschemaEdge{ptr: joinPtr(ptr, keyword), target: joinPtr(ptr, keyword)})
+		}
+	}
+	dependencies := asMap(m["dependencies"])
+	for _, name := range sortedKeys(dependencies) {
+		if _, ok := dependencies[name].(map[string]interface{}); ok {
+			dependencyPtr := joinPtr(joinPtr(ptr, "dependencies"), name)
+			edges = append(edges, subschemaEdge{ptr: dependencyPtr, target: dependencyPtr})
+		}
+	}
+	return edges
+}
+
+func resolveLocalPtr(root interface{}, ptr string) (interface{}, bool) {
+	value := root
+	for _, token := range splitPtr(ptr) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			value = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// toSchemaCompileError converts the schema compiler error into a SchemaCompileError,
+// pointing at the deepest failing part of the schema.
+func toSchemaCompileError(err error) error {
+	var schemaErr *jsonschema.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return &SchemaCompileError{Reason: err.Error(), Err: err}
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(schemaErr.Err, &validationErr) {
+		return &SchemaCompileError{Reason: schemaErr.Err.Error(), Err: err}
+	}
+
+	leaf := deepestCause(validationErr)
+	return &SchemaCompileError{
+		SchemaPtr: normalizeSchemaPtr(leaf.InstancePtr),
+		Reason:    fmt.Sprintf("doesn't validate against the JSON Schema meta-schema: %s", leaf.Message),
+		Err:       err,
+	}
+}
+
+func deepestCause(err *jsonschema.ValidationError) *jsonschema.ValidationError {
+	deepest, depth := err, 0
+	var walk func(e *jsonschema.ValidationError, d int)
+	walk = func(e *jsonschema.ValidationError, d int) {
+		if len(e.Causes) == 0 && d > depth {
+			deepest, depth = e, d
+		}
+		for _, cause := range e.Causes {
+			walk(cause, d+1)
+		}
+	}
+	walk(err, 0)
+	return deepest
+}
+
+// normalizeSchemaPtr removes the duplicated root marker the compiler reports meta-schema issues with, like #/#/definitions.
+func normalizeSchemaPtr(ptr string) string {
+	for strings.HasPrefix(ptr, "#/#") {
+		ptr = strings.TrimPrefix(ptr, "#/")
+	}
+	return ptr
+}
+
+// offsetPosition returns the line and column of the last read byte, json.SyntaxError offsets point after it.
+func offsetPosition(str string, offset int64) (line int, column int) {
+	if offset > int64(len(str)) {
+		offset = int64(len(str))
+	}
+	if offset > 0 {
+		offset--
+	}
+	before := str[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewJSONSchemaValidator_SchemaCompileError(t *testing.T) {
+	tests := []struct {
+		name          string
+		schema        string
+		wantSchemaPtr string
+		wantErr       string
+	}{
+		{
+			name:    "invalid JSON",
+			schema:  "{\n  \"type\": \"object\",\n  \"properties\": {\n}",
+			wantErr: "schema compilation failed: invalid JSON: unexpected EOF",
+		},
+		{
+			name:    "JSON syntax error",
+			schema:  "{\n  \"type\": \"object\"\n  \"required\": []\n}",
+			wantErr: "schema compilation failed: invalid JSON at line 3, column 3: invalid character '\"' after object key:value pair",
+		},
+		{
+			name:          "invalid patternProperties pattern",
+			schema:        `{"definitions": {"WithModel": {"properties": {"steps": {"items": {"patternProperties": {"^(?<!with$": {}}}}}}}}`,
+			wantSchemaPtr: "#/definitions/WithModel/properties/steps/items/patternProperties",
+		},
+		{
+			name:          "meta-schema violation",
+			schema:        `{"properties": {"title": {"type": "strin"}}}`,
+			wantSchemaPtr: "#/properties/title/type",
+			wantErr:       `schema compilation failed at #/properties/title/type: doesn't validate against the JSON Schema meta-schema: value must be one of "array", "boolean", "integer", "null", "number", "object", "string"`,
+		},
+		{
+			name:          "missing $ref target",
+			schema:        `{"properties": {"steps": {"items": {"$ref": "#/definitions/StepModel"}}}}`,
+			wantSchemaPtr: "#/properties/steps/items/$ref",
+			wantErr:       `schema compilation failed at #/properties/steps/items/$ref: $ref "#/definitions/StepModel" points to a missing schema`,
+		},
+		{
+			name:          "circular $ref",
+			schema:        `{"$ref": "#/definitions/A", "definitions": {"A": {"$ref": "#/definitions/B"}, "B": {"$ref": "#/definitions/A"}}}`,
+			wantSchemaPtr: "#/$ref",
+			wantErr:       `schema compilation failed at #/$ref: circular $ref: #/definitions/A -> #/definitions/B -> #/definitions/A`,
+		},
+		{
+			name:          "missing $id anchor",
+			schema:        `{"definitions": {"A": {"$id": "#foo"}}, "properties": {"a": {"$ref": "#bar"}}}`,
+			wantSchemaPtr: "#/properties/a/$ref",
+			wantErr:       `schema compilation failed at #/properties/a/$ref: $ref "#bar" points to a missing schema`,
+		},
+		{
+			name:          "$id anchor under patternProperties",
+			schema:        `{"patternProperties": {"^a$": {"$id": "#foo"}}, "properties": {"b": {"$ref": "#foo"}}}`,
+			wantSchemaPtr: "#/properties/b/$ref",
+			wantErr:       `schema compilation failed at #/properties/b/$ref: $ref "#foo" points to the $id of a patternProperties or additionalProperties schema at #/patternProperties/%5Ea$, which can't be referred to by its $id, as these keywords are evaluated with ECMA-262 regex semantics`,
+		},
+		{
+			name:          "circular $ref through $id anchor",
+			schema:        `{"definitions": {"A": {"$id": "#foo", "$ref": "#/definitions/B"}, "B": {"$ref": "#foo"}}, "properties": {"a": {"$ref": "#foo"}}}`,
+			wantSchemaPtr: "#/definitions/A/$ref",
+			wantErr:       `schema compilation failed at #/definitions/A/$ref: circular $ref: #/definitions/B -> #foo -> #/definitions/B`,
+		},
+		{
+			name:          "$ref cycle through not",
+			schema:        `{"not": {"$ref": "#"}}`,
+			wantSchemaPtr: "#/not/$ref",
+			wantErr:       `schema compilation failed at #/not/$ref: schema applies itself to the same instance: # -> #/not -> #`,
+		},
+		{
+			name:          "$ref cycle through allOf",
+			schema:        `{"allOf": [{"$ref": "#"}]}`,
+			wantSchemaPtr: "#/allOf/0/$ref",
+			wantErr:       `schema compilation failed at #/allOf/0/$ref: schema applies itself to the same instance: # -> #/allOf/0 -> #`,
+		},
+		{
+			name:          "$ref cycle through definitions",
+			schema:        `{"definitions": {"A": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/B"}]}, "B": {"if": {"$ref": "#/definitions/A"}}}, "properties": {"a": {"$ref": "#/definitions/A"}}}`,
+			wantSchemaPtr: "#/definitions/B/if/$ref",
+			wantErr:       `schema compilation failed at #/definitions/B/if/$ref: schema applies itself to the same instance: #/definitions/A -> #/definitions/A/anyOf/1 -> #/definitions/B -> #/definitions/B/if -> #/definitions/A`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJSONSchemaValidator(tt.schema)
+			var compileErr *SchemaCompileError
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("expected SchemaCompileError, got: %#v", err)
+			}
+			if compileErr.SchemaPtr != tt.wantSchemaPtr {
+				t.Errorf("SchemaPtr = %s, want %s", compileErr.SchemaPtr, tt.wantSchemaPtr)
+			}
+			if tt.wantErr != "" && err.Error() != tt.wantErr {
+				t.Errorf("Error() = %s, want %s", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewJSONSchemaValidator_IDAnchorRef(t *testing.T) {
+	schema := `{
+  "$id": "https://example.com/schema.json",
+  "definitions": {
+    "Name": {
+      "$id": "#name",
+      "type": "string"
+    }
+  },
+  "properties": {
+    "title": {
+      "$ref": "#name"
+    }
+  }
+}`
+	v, err := NewJSONSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateIssues("title: 1")
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{`I[#/title] S[#name/type] expected string, but got number`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateIssues() got = %v, want %v", got, want)
+	}
+}
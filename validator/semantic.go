@@ -0,0 +1,136 @@
+package validator
+
+import (
+	"fmt"
+)
+
+const (
+	KeywordUndefinedWorkflow = "undefinedWorkflow"
+	KeywordUndefinedPipeline = "undefinedPipeline"
+	KeywordUndefinedStage    = "undefinedStage"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordUndefinedWorkflow: "Reference to an undefined workflow",
+	KeywordUndefinedPipeline: "Reference to an undefined pipeline",
+	KeywordUndefinedStage:    "Reference to an undefined stage",
+})
+
+// SemanticCheck reports issues of a decoded document the JSON schema can't express, like dangling references.
+type SemanticCheck func(document interface{}) []ValidationIssue
+
+// WithSemanticChecks runs the given checks on every validated document, after the schema validation.
+// Their issues are reported, located and classified by the warning rules the same way as schema issues.
+func WithSemanticChecks(checks ...SemanticCheck) Option {
+	return func(v *JSONSchemaValidator) {
+		v.semanticChecks = append(v.semanticChecks, checks...)
+	}
+}
+
+// schemaSupersedingKeywords are the keywords of the semantic issues which explain the schema issues
+// at the same instance pointer, those schema issues are left out. The files defining these keywords
+// register them with supersedeSchemaIssues.
+var schemaSupersedingKeywords = map[string]bool{}
+
+// supersedeSchemaIssues registers the keywords in schemaSupersedingKeywords.
+// It returns true, so that it can initialize a package level variable next to the keywords.
+func supersedeSchemaIssues(keywords ...string) bool {
+	for _, keyword := range keywords {
+		schemaSupersedingKeywords[keyword] = true
+	}
+	return true
+}
+
+// dropSupersededSchemaIssues leaves out the schema issues, issues[schemaStart:schemaEnd], which are at the instance
+// pointer of a semantic issue with a schema superseding keyword.
+func dropSupersededSchemaIssues(issues []ValidationIssue, schemaStart, schemaEnd int) []ValidationIssue {
+	superseded := map[string]bool{}
+	for _, issue := range issues[schemaEnd:] {
+		if schemaSupersedingKeywords[issue.Keyword] {
+			superseded[issue.InstancePtr] = true
+		}
+	}
+	if len(superseded) == 0 {
+		return issues
+	}
+
+	kept := append([]ValidationIssue{}, issues[:schemaStart]...)
+	for _, issue := range issues[schemaStart:schemaEnd] {
+		if !superseded[issue.InstancePtr] {
+			kept = append(kept, issue)
+		}
+	}
+	return append(kept, issues[schemaEnd:]...)
+}
+
+// BitriseYMLSemanticChecks returns every semantic check of the bitrise.yml.
+func BitriseYMLSemanticChecks() []SemanticCheck {
+	return []SemanticCheck{
+		CheckBitriseYMLReferences,
+		CheckPipelineGraphs,
+		CheckWorkflowRecursion,
+		CheckStepBundles,
+		CheckContainers,
+	}
+}
+
+// CheckBitriseYMLReferences reports trigger_map items, before_run and after_run lists and pipeline stages
+// referring to workflows, pipelines or stages which are not defined in the bitrise.yml.
+func CheckBitriseYMLReferences(document interface{}) []ValidationIssue {
+	model := LoadBitriseYML(document)
+
+	var issues []ValidationIssue
+	for _, item := range model.TriggerMap {
+		if item.Pipeline != nil {
+			if _, ok := model.Pipelines[item.Pipeline.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(*item.Pipeline, "pipeline", KeywordUndefinedPipeline))
+			}
+		}
+		if item.Workflow != nil {
+			if _, ok := model.Workflows[item.Workflow.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(*item.Workflow, "workflow", KeywordUndefinedWorkflow))
+			}
+		}
+	}
+
+	for _, name := range model.PipelineNames() {
+		for _, stage := range model.Pipelines[name].Stages {
+			if _, ok := model.Stages[stage.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(stage, "stage", KeywordUndefinedStage))
+			}
+		}
+	}
+
+	for _, name := range model.StageNames() {
+		for _, workflow := range model.Stages[name].Workflows {
+			if _, ok := model.Workflows[workflow.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(workflow, "workflow", KeywordUndefinedWorkflow))
+			}
+		}
+	}
+
+	for _, name := range model.WorkflowNames() {
+		workflow := model.Workflows[name]
+		for _, before := range workflow.BeforeRun {
+			if _, ok := model.Workflows[before.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(before, "before_run workflow", KeywordUndefinedWorkflow))
+			}
+		}
+		for _, after := range workflow.AfterRun {
+			if _, ok := model.Workflows[after.Name]; !ok {
+				issues = append(issues, undefinedReferenceIssue(after, "after_run workflow", KeywordUndefinedWorkflow))
+			}
+		}
+	}
+
+	return issues
+}
+
+func undefinedReferenceIssue(reference Reference, kind, keyword string) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: reference.Ptr,
+		SchemaPtr:   semanticSchemaPtr(keyword),
+		Message:     fmt.Sprintf("%s %q is not defined", kind, reference.Name),
+		Keyword:     keyword,
+	}
+}
@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const bitriseYMLWithDanglingReferences = `format_version: "13"
+trigger_map:
+- push_branch: main
+  workflow: deploy
+- pull_request_source_branch: "*"
+  pipeline: missing_pipeline
+pipelines:
+  ci:
+    stages:
+    - build: {}
+    - missing_stage: {}
+stages:
+  build:
+    workflows:
+    - test: {}
+    - missing_stage_workflow: {}
+workflows:
+  test:
+    before_run:
+    - setup
+    - missing_before
+    after_run:
+    - missing_after
+  setup: {}
+`
+
+func decodeTestYAML(t *testing.T, ymlStr string) interface{} {
+	var m interface{}
+	if err := yaml.Unmarshal([]byte(ymlStr), &m); err != nil {
+		t.Fatalf("failed to decode YAML: %s", err)
+	}
+	return recursiveJSONMarshallable(m)
+}
+
+func TestCheckBitriseYMLReferences(t *testing.T) {
+	issues := CheckBitriseYMLReferences(decodeTestYAML(t, bitriseYMLWithDanglingReferences))
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/trigger_map/0/workflow", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `workflow "deploy" is not defined`, Keyword: KeywordUndefinedWorkflow},
+		{InstancePtr: "#/trigger_map/1/pipeline", SchemaPtr: semanticSchemaPtr(KeywordUndefinedPipeline), Message: `pipeline "missing_pipeline" is not defined`, Keyword: KeywordUndefinedPipeline},
+		{InstancePtr: "#/pipelines/ci/stages/1/missing_stage", SchemaPtr: semanticSchemaPtr(KeywordUndefinedStage), Message: `stage "missing_stage" is not defined`, Keyword: KeywordUndefinedStage},
+		{InstancePtr: "#/stages/build/workflows/1/missing_stage_workflow", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `workflow "missing_stage_workflow" is not defined`, Keyword: KeywordUndefinedWorkflow},
+		{InstancePtr: "#/workflows/test/before_run/1", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `before_run workflow "missing_before" is not defined`, Keyword: KeywordUndefinedWorkflow},
+		{InstancePtr: "#/workflows/test/after_run/0", SchemaPtr: semanticSchemaPtr(KeywordUndefinedWorkflow), Message: `after_run workflow "missing_after" is not defined`, Keyword: KeywordUndefinedWorkflow},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckBitriseYMLReferences() got = %#v, want %#v", issues, want)
+	}
+}
+
+func TestJSONSchemaValidator_WithSemanticChecks(t *testing.T) {
+	rules, err := NewWarningRules(`after_run workflow`)
+	if err != nil {
+		t.Fatalf("NewWarningRules() error = %v", err)
+	}
+	v, err := NewJSONSchemaValidator(`{}`, WithSemanticChecks(BitriseYMLSemanticChecks()...), WithWarningRules(rules))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	issues, err := v.ValidateIssues(bitriseYMLWithDanglingReferences)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+	if len(issues) != 6 {
+		t.Fatalf("ValidateIssues() got %d issues, want 6: %v", len(issues), issues)
+	}
+
+	first, last := issues[0], issues[len(issues)-1]
+	if first.Line != 4 || first.Column != 3 || first.Severity != SeverityError {
+		t.Errorf("unexpected first issue: %#v", first)
+	}
+	if last.Line != 23 || last.Column != 7 || last.Severity != SeverityWarning {
+		t.Errorf("unexpected last issue: %#v", last)
+	}
+	if got, want := first.String(), `I[#/trigger_map/0/workflow] S[#/x-semantic/undefinedWorkflow] workflow "deploy" is not defined`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
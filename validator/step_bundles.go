@@ -0,0 +1,151 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	KeywordUndefinedStepBundle          = "undefinedStepBundle"
+	KeywordUnknownBundleInput           = "unknownBundleInput"
+	KeywordStepBundleCycle              = "stepBundleCycle"
+	KeywordUnusedStepBundle             = "unusedStepBundle"
+	KeywordMalformedStepBundleReference = "malformedStepBundleReference"
+)
+
+var _ = describeRules(map[string]string{
+	KeywordUndefinedStepBundle:          "Reference to an undefined step bundle",
+	KeywordUnknownBundleInput:           "Input not declared by the step bundle",
+	KeywordStepBundleCycle:              "Step bundle running itself through its step bundle references",
+	KeywordUnusedStepBundle:             "Step bundle not used by any workflow",
+	KeywordMalformedStepBundleReference: "Step bundle reference without a step bundle name",
+})
+
+// The schema rejects a step bundle reference without a name as an unknown property of the step list item.
+var _ = supersedeSchemaIssues(KeywordMalformedStepBundleReference)
+
+const stepBundleIDPrefix = "bundle::"
+
+// CheckStepBundles reports the step bundle references of workflows and step bundles which don't name a step bundle,
+// refer to undefined step bundles or override inputs the step bundle doesn't declare, the step bundles referring
+// to themselves through their steps, with the full chain of step bundles, and the step bundles no workflow runs.
+func CheckStepBundles(document interface{}) []ValidationIssue {
+	model := LoadBitriseYML(document)
+
+	var issues []ValidationIssue
+	checkReferences := func(steps []Step) {
+		for _, step := range steps {
+			name, ok := stepBundleName(step)
+			if !ok {
+				continue
+			}
+			if name == "" {
+				issues = append(issues, ValidationIssue{
+					InstancePtr: step.Ptr,
+					SchemaPtr:   semanticSchemaPtr(KeywordMalformedStepBundleReference),
+					Message:     fmt.Sprintf("step bundle reference %q doesn't name a step bundle", step.ID),
+					Keyword:     KeywordMalformedStepBundleReference,
+				})
+				continue
+			}
+			bundle, ok := model.StepBundles[name]
+			if !ok {
+				issues = append(issues, undefinedReferenceIssue(Reference{Name: name, Ptr: joinPtr(step.Ptr, step.ID)}, "step bundle", KeywordUndefinedStepBundle))
+				continue
+			}
+			for _, input := range step.Inputs {
+				if !containsReference(bundle.Inputs, input.Name) {
+					issues = append(issues, ValidationIssue{
+						InstancePtr: input.Ptr,
+						SchemaPtr:   semanticSchemaPtr(KeywordUnknownBundleInput),
+						Message:     fmt.Sprintf("input %q is not declared by step bundle %q", input.Name, name),
+						Keyword:     KeywordUnknownBundleInput,
+					})
+				}
+			}
+		}
+	}
+	for _, name := range model.WorkflowNames() {
+		checkReferences(model.Workflows[name].Steps)
+	}
+	for _, name := range model.StepBundleNames() {
+		checkReferences(model.StepBundles[name].Steps)
+	}
+
+	nested := map[string][]string{}
+	// referencePtrs are the JSON pointers of the first step bundle references of the graph edges.
+	referencePtrs := map[string]map[string]string{}
+	for _, name := range model.StepBundleNames() {
+		referencePtrs[name] = map[string]string{}
+		for _, step := range model.StepBundles[name].Steps {
+			nestedName, ok := stepBundleName(step)
+			if _, defined := model.StepBundles[nestedName]; !ok || !defined {
+				continue
+			}
+			if _, ok := referencePtrs[name][nestedName]; ok {
+				continue
+			}
+			nested[name] = append(nested[name], nestedName)
+			referencePtrs[name][nestedName] = joinPtr(step.Ptr, step.ID)
+		}
+	}
+	for _, cycle := range dependencyCycles(model.StepBundleNames(), nested) {
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		issues = append(issues, ValidationIssue{
+			InstancePtr: referencePtrs[from][to],
+			SchemaPtr:   semanticSchemaPtr(KeywordStepBundleCycle),
+			Message:     fmt.Sprintf("step bundle cycle: %s", strings.Join(cycle, " -> ")),
+			Keyword:     KeywordStepBundleCycle,
+		})
+	}
+
+	used := map[string]bool{}
+	var use func(name string)
+	use = func(name string) {
+		if used[name] {
+			return
+		}
+		used[name] = true
+		for _, nestedName := range nested[name] {
+			use(nestedName)
+		}
+	}
+	for _, name := range model.WorkflowNames() {
+		for _, step := range model.Workflows[name].Steps {
+			if bundleName, ok := stepBundleName(step); ok {
+				if _, defined := model.StepBundles[bundleName]; defined {
+					use(bundleName)
+				}
+			}
+		}
+	}
+	for _, name := range model.StepBundleNames() {
+		if !used[name] {
+			issues = append(issues, ValidationIssue{
+				InstancePtr: model.StepBundles[name].Ptr,
+				SchemaPtr:   semanticSchemaPtr(KeywordUnusedStepBundle),
+				Message:     fmt.Sprintf("step bundle %q is not used by any workflow", name),
+				Keyword:     KeywordUnusedStepBundle,
+			})
+		}
+	}
+
+	return issues
+}
+
+// stepBundleName returns the name of the step bundle the step refers to, if it is a step bundle reference.
+func stepBundleName(step Step) (string, bool) {
+	if !strings.HasPrefix(step.ID, stepBundleIDPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(step.ID, stepBundleIDPrefix), true
+}
+
+func containsReference(references []Reference, name string) bool {
+	for _, reference := range references {
+		if reference.Name == name {
+			return true
+		}
+	}
+	return false
+}
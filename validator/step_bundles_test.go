@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+const bitriseYMLWithStepBundles = `format_version: "13"
+step_bundles:
+  install:
+    inputs:
+    - VERSION: "1.0"
+      opts:
+        is_required: true
+    steps:
+    - script@1: {}
+  test:
+    steps:
+    - bundle::install:
+        inputs:
+        - VERSION: "2.0"
+        - CACHE: "true"
+    - bundle::missing: {}
+  a:
+    steps:
+    - bundle::b: {}
+  b:
+    steps:
+    - bundle::a: {}
+  unused: {}
+workflows:
+  primary:
+    steps:
+    - bundle::test: {}
+    - bundle::install:
+        inputs:
+        - version: "3.0"
+    - bundle::undefined: {}
+    - "bundle::": {}
+    - bundle::: {}
+`
+
+func TestCheckStepBundles(t *testing.T) {
+	issues := CheckStepBundles(decodeTestYAML(t, bitriseYMLWithStepBundles))
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/workflows/primary/steps/1/bundle::install/inputs/0/version", SchemaPtr: semanticSchemaPtr(KeywordUnknownBundleInput), Message: `input "version" is not declared by step bundle "install"`, Keyword: KeywordUnknownBundleInput},
+		{InstancePtr: "#/workflows/primary/steps/2/bundle::undefined", SchemaPtr: semanticSchemaPtr(KeywordUndefinedStepBundle), Message: `step bundle "undefined" is not defined`, Keyword: KeywordUndefinedStepBundle},
+		{InstancePtr: "#/workflows/primary/steps/3", SchemaPtr: semanticSchemaPtr(KeywordMalformedStepBundleReference), Message: `step bundle reference "bundle::" doesn't name a step bundle`, Keyword: KeywordMalformedStepBundleReference},
+		{InstancePtr: "#/workflows/primary/steps/4", SchemaPtr: semanticSchemaPtr(KeywordMalformedStepBundleReference), Message: `step bundle reference "bundle::" doesn't name a step bundle`, Keyword: KeywordMalformedStepBundleReference},
+		{InstancePtr: "#/step_bundles/test/steps/0/bundle::install/inputs/1/CACHE", SchemaPtr: semanticSchemaPtr(KeywordUnknownBundleInput), Message: `input "CACHE" is not declared by step bundle "install"`, Keyword: KeywordUnknownBundleInput},
+		{InstancePtr: "#/step_bundles/test/steps/1/bundle::missing", SchemaPtr: semanticSchemaPtr(KeywordUndefinedStepBundle), Message: `step bundle "missing" is not defined`, Keyword: KeywordUndefinedStepBundle},
+		{InstancePtr: "#/step_bundles/b/steps/0/bundle::a", SchemaPtr: semanticSchemaPtr(KeywordStepBundleCycle), Message: "step bundle cycle: a -> b -> a", Keyword: KeywordStepBundleCycle},
+		{InstancePtr: "#/step_bundles/a", SchemaPtr: semanticSchemaPtr(KeywordUnusedStepBundle), Message: `step bundle "a" is not used by any workflow`, Keyword: KeywordUnusedStepBundle},
+		{InstancePtr: "#/step_bundles/b", SchemaPtr: semanticSchemaPtr(KeywordUnusedStepBundle), Message: `step bundle "b" is not used by any workflow`, Keyword: KeywordUnusedStepBundle},
+		{InstancePtr: "#/step_bundles/unused", SchemaPtr: semanticSchemaPtr(KeywordUnusedStepBundle), Message: `step bundle "unused" is not used by any workflow`, Keyword: KeywordUnusedStepBundle},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckStepBundles() =\n%#v\nwant\n%#v", issues, want)
+	}
+}
+
+func TestCheckStepBundles_Locations(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.BitriseSchema, WithSemanticChecks(CheckStepBundles))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+	issues, err := v.ValidateIssues(bitriseYMLWithStepBundles)
+	if err != nil {
+		t.Fatalf("ValidateIssues() error = %v", err)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%d:%d %s", issue.Line, issue.Column, issue.Keyword))
+	}
+	sort.Strings(got)
+	want := []string{
+		"15:11 " + KeywordUnknownBundleInput,
+		"16:7 " + KeywordUndefinedStepBundle,
+		"17:3 " + KeywordUnusedStepBundle,
+		"20:3 " + KeywordUnusedStepBundle,
+		"22:7 " + KeywordStepBundleCycle,
+		"23:3 " + KeywordUnusedStepBundle,
+		"30:11 " + KeywordUnknownBundleInput,
+		"31:7 " + KeywordUndefinedStepBundle,
+		"32:7 " + KeywordMalformedStepBundleReference,
+		"33:7 " + KeywordMalformedStepBundleReference,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateIssues() locations =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package validator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v3"
+)
+
+// maxSuggestions is the maximum number of suggestions attached to an issue.
+const maxSuggestions = 3
+
+// schemaIndex maps the JSON pointers of the compiled schema's subschemas to the subschemas.
+type schemaIndex map[string]*jsonschema.Schema
+
+func newSchemaIndex(root *jsonschema.Schema) schemaIndex {
+	index := schemaIndex{}
+	index.add(root, "#")
+	return index
+}
+
+// add indexes the schema and its subschemas. Only referenced schemas know their own pointer,
+// the pointers of the inline subschemas are built while walking the schema.
+func (index schemaIndex) add(s *jsonschema.Schema, ptr string) {
+	if s == nil {
+		return
+	}
+	if _, ok := index[ptr]; ok {
+		return
+	}
+	index[ptr] = s
+
+	if s.Ref != nil {
+		index.add(s.Ref, s.Ref.Ptr)
+	}
+	index.add(s.Not, joinPtr(ptr, "not"))
+	index.add(s.If, joinPtr(ptr, "if"))
+	index.add(s.Then, joinPtr(ptr, "then"))
+	index.add(s.Else, joinPtr(ptr, "else"))
+	index.add(s.PropertyNames, joinPtr(ptr, "propertyNames"))
+	index.add(s.Contains, joinPtr(ptr, "contains"))
+	index.addList(s.AllOf, joinPtr(ptr, "allOf"))
+	index.addList(s.AnyOf, joinPtr(ptr, "anyOf"))
+	index.addList(s.OneOf, joinPtr(ptr, "oneOf"))
+	for name, subschema := range s.Properties {
+		index.add(subschema, joinPtr(joinPtr(ptr, "properties"), name))
+	}
+	for re, subschema := range s.PatternProperties {
+		index.add(subschema, joinPtr(joinPtr(ptr, "patternProperties"), re.String()))
+	}
+	for name, dependency := range s.Dependencies {
+		if subschema, ok := dependency.(*jsonschema.Schema); ok {
+			index.add(subschema, joinPtr(joinPtr(ptr, "dependencies"), name))
+		}
+	}
+	if subschema, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+		index.add(subschema, joinPtr(ptr, "additionalProperties"))
+	}
+	switch items := s.Items.(type) {
+	case *jsonschema.Schema:
+		index.add(items, joinPtr(ptr, "items"))
+	case []*jsonschema.Schema:
+		index.addList(items, joinPtr(ptr, "items"))
+	}
+	if subschema, ok := s.AdditionalItems.(*jsonschema.Schema); ok {
+		index.add(subschema, joinPtr(ptr, "additionalItems"))
+	}
+
+	if ecma, ok := s.Extensions[ecmaRegexExtensionName].(*ecmaRegexSchema); ok {
+		for _, property := range ecma.patternProperties {
+			index.add(property.schema, joinPtr(joinPtr(ptr, "patternProperties"), property.pattern))
+		}
+		if subschema, ok := ecma.additionalProperties.(*jsonschema.Schema); ok {
+			index.add(subschema, joinPtr(ptr, "additionalProperties"))
+		}
+	}
+}
+
+func (index schemaIndex) addList(subschemas []*jsonschema.Schema, ptr string) {
+	for idx, subschema := range subschemas {
+		index.add(subschema, joinPtr(ptr, strconv.Itoa(idx)))
+	}
+}
+
+// suggestions returns the valid property names or enum values nearest to the offending ones of the issue.
+// The document is the validated document, the invalid enum values are looked up in it.
+func (index schemaIndex) suggestions(issue ValidationIssue, document interface{}) []string {
+	parentPtr := strings.TrimSuffix(issue.SchemaPtr, "/"+issue.Keyword)
+	schema, ok := index[parentPtr]
+	if !ok {
+		return nil
+	}
+
+	switch issue.Keyword {
+	case "additionalProperties":
+		candidates := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			candidates = append(candidates, name)
+		}
+		var suggestions []string
+		for _, name := range quotedNames(issue.Message) {
+			suggestions = appendUnique(suggestions, nearestCandidates(name, candidates)...)
+		}
+		return limitSuggestions(suggestions)
+	case "enum":
+		value, ok := resolveLocalPtr(document, issue.InstancePtr)
+		if !ok {
+			return nil
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		var candidates []string
+		for _, item := range schema.Enum {
+			if candidate, ok := item.(string); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+		return limitSuggestions(nearestCandidates(str, candidates))
+	}
+
+	return nil
+}
+
+// nearestCandidates returns the candidates nearest to the word, in alphabetical order.
+// Candidates further than a typo's distance are left out: at most a third of the word's characters can be mistyped.
+func nearestCandidates(word string, candidates []string) []string {
+	maxDistance := len(word) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	var nearest []string
+	for _, candidate := range candidates {
+		if candidate == word {
+			continue
+		}
+		distance := editDistance(strings.ToLower(word), strings.ToLower(candidate))
+		if distance > maxDistance {
+			continue
+		}
+		if distance < maxDistance {
+			maxDistance = distance
+			nearest = nil
+		}
+		nearest = append(nearest, candidate)
+	}
+	sort.Strings(nearest)
+	return nearest
+}
+
+// editDistance returns the optimal string alignment distance of the strings:
+// the number of inserted, deleted, substituted and transposed adjacent characters.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+func appendUnique(values []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, value := range values {
+			if value == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func limitSuggestions(suggestions []string) []string {
+	if len(suggestions) > maxSuggestions {
+		return suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestJSONSchemaValidator_Suggestions(t *testing.T) {
+	tests := []struct {
+		name            string
+		schema          string
+		ymlStr          string
+		wantInstancePtr string
+		want            []string
+	}{
+		{
+			name:   "misspelled workflow key",
+			schema: schemas.BitriseSchema,
+			ymlStr: `format_version: "13"
+workflows:
+  test:
+    befor_run:
+    - setup
+  setup: {}
+`,
+			wantInstancePtr: "#/workflows/test",
+			want:            []string{"before_run"},
+		},
+		{
+			name:   "misspelled trigger map key",
+			schema: schemas.BitriseSchema,
+			ymlStr: `format_version: "13"
+trigger_map:
+- pull_request_sorce_branch: "*"
+  workflow: test
+workflows:
+  test: {}
+`,
+			wantInstancePtr: "#/trigger_map/0",
+			want:            []string{"pull_request_source_branch"},
+		},
+		{
+			name:   "misspelled input option",
+			schema: schemas.StepSchema,
+			ymlStr: validStepYML + `inputs:
+- token:
+  opts:
+    title: Token
+    summary: Access token
+    is_sensitiv: true
+`,
+			wantInstancePtr: "#/inputs/0/opts",
+			want:            []string{"is_sensitive"},
+		},
+		{
+			name:            "misspelled enum value",
+			schema:          schemas.StepSchema,
+			ymlStr:          validStepYML + "project_type_tags:\n- andriod\n",
+			wantInstancePtr: "#/project_type_tags/0",
+			want:            []string{"android"},
+		},
+		{
+			name:            "no near enum value",
+			schema:          schemas.StepSchema,
+			ymlStr:          validStepYML + "project_type_tags:\n- windows\n",
+			wantInstancePtr: "#/project_type_tags/0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewJSONSchemaValidator(tt.schema)
+			if err != nil {
+				t.Fatalf("Failed to create validator: %s", err)
+			}
+			issues, err := v.ValidateIssues(tt.ymlStr)
+			if err != nil {
+				t.Fatalf("ValidateIssues() error = %v", err)
+			}
+			if len(issues) != 1 {
+				t.Fatalf("ValidateIssues() = %v, want a single issue", issues)
+			}
+			if issues[0].InstancePtr != tt.wantInstancePtr {
+				t.Errorf("InstancePtr = %s, want %s", issues[0].InstancePtr, tt.wantInstancePtr)
+			}
+			if !reflect.DeepEqual(issues[0].Suggestions, tt.want) {
+				t.Errorf("Suggestions = %v, want %v", issues[0].Suggestions, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nearestCandidates(t *testing.T) {
+	candidates := []string{"before_run", "after_run", "steps", "envs"}
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "befor_run", want: []string{"before_run"}},
+		{word: "Before_Run", want: []string{"before_run"}},
+		{word: "setps", want: []string{"steps"}},
+		{word: "env", want: []string{"envs"}},
+		{word: "description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := nearestCandidates(tt.word, candidates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearestCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,134 +1,275 @@
 package validator
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"io"
 
 	"github.com/santhosh-tekuri/jsonschema/v3"
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 type JSONSchemaValidator struct {
-	schema *jsonschema.Schema
+	schema              *jsonschema.Schema
+	schemaIndex         schemaIndex
+	warningRules        WarningRules
+	rejectMultiDocument bool
+	semanticChecks      []SemanticCheck
+	allAlternatives     bool
+	maxIncludeDepth     int
 }
 
-func NewJSONSchemaValidator(schemaStr string) (*JSONSchemaValidator, error) {
-	compiler := jsonschema.NewCompiler()
-	if err := compiler.AddResource("schema.json", strings.NewReader(schemaStr)); err != nil {
-		return nil, err
+// Option configures a JSONSchemaValidator.
+type Option func(v *JSONSchemaValidator)
+
+// WithWarningRules makes the validator report issues matching the given rules as warnings in every validation.
+func WithWarningRules(rules WarningRules) Option {
+	return func(v *JSONSchemaValidator) {
+		v.warningRules = rules
 	}
-	schema, err := compiler.Compile("schema.json")
+}
+
+func NewJSONSchemaValidator(schemaStr string, opts ...Option) (*JSONSchemaValidator, error) {
+	schema, err := compileSchema(schemaStr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &JSONSchemaValidator{
-		schema: schema,
-	}, nil
+	v := &JSONSchemaValidator{
+		schema:      schema,
+		schemaIndex: newSchemaIndex(schema),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v, nil
 }
 
 func (v JSONSchemaValidator) Validate(ymlStr string, warningPatterns ...string) (warns []string, errs []string, err error) {
-	var m interface{}
-	err = yaml.Unmarshal([]byte(ymlStr), &m)
+	issues, err := v.ValidateIssues(ymlStr, warningPatterns...)
 	if err != nil {
 		return nil, nil, err
 	}
-	m, err = recursiveJSONMarshallable(m)
+
+	for _, issue := range issues {
+		if issue.Severity == SeverityWarning {
+			warns = append(warns, issue.String())
+		} else {
+			errs = append(errs, issue.String())
+		}
+	}
+
+	return warns, errs, nil
+}
+
+// ValidateIssues validates the given YAML document and returns the found issues.
+// Issues matching any of the warning patterns (in their String form) or the validator's warning rules
+// are reported with SeverityWarning. An invalid warning pattern is returned as an error.
+//
+// Besides the schema violations, duplicate and non-string mapping keys are reported as issues too.
+func (v JSONSchemaValidator) ValidateIssues(ymlStr string, warningPatterns ...string) ([]ValidationIssue, error) {
+	if v.rejectMultiDocument {
+		documents, err := decodeYAMLDocuments([]byte(ymlStr))
+		if err != nil {
+			return nil, err
+		}
+		if len(documents) > 1 {
+			return nil, ErrMultiDocument
+		}
+	}
+
+	var m interface{}
+	err := yaml.Unmarshal([]byte(ymlStr), &m)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	m = recursiveJSONMarshallable(m)
+
+	// Like in locateIssues, the key checks and the issue positions are best effort:
+	// if yaml.v3 can't parse the source yaml.v2 decoded, the document is validated without them.
+	var root *yamlv3.Node
+	roots, err := parseNodeTrees([]byte(ymlStr))
+	if err == nil && len(roots) > 0 {
+		root = roots[0]
+	}
+	issues, err := v.validateDocument(m, 0, keyIssues(root), warningPatterns)
+	if err != nil {
+		return nil, err
+	}
+	locateIssuesInRoots(issues, roots)
+
+	return issues, nil
+}
+
+// ValidateJSON validates the given JSON document and returns the found issues.
+// Numbers are decoded as json.Number, so they are validated without losing precision.
+func (v JSONSchemaValidator) ValidateJSON(jsonBytes []byte, warningPatterns ...string) ([]ValidationIssue, error) {
+	m, err := decodeJSON(bytes.NewReader(jsonBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := v.validateDocument(m, 0, nil, warningPatterns)
+	if err != nil {
+		return nil, err
+	}
+	locateIssues(issues, jsonBytes)
+
+	return issues, nil
+}
+
+// ValidateReader reads and validates a JSON document, see ValidateJSON.
+func (v JSONSchemaValidator) ValidateReader(r io.Reader, warningPatterns ...string) ([]ValidationIssue, error) {
+	jsonBytes, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ValidateJSON(jsonBytes, warningPatterns...)
+}
+
+// ValidateValue validates an already decoded document.
+// The value has to be built of JSON compatible types, like the ones encoding/json decodes into interface{}.
+// Issues of a decoded value carry no source location.
+func (v JSONSchemaValidator) ValidateValue(value interface{}, warningPatterns ...string) ([]ValidationIssue, error) {
+	return v.validateDocument(value, 0, nil, warningPatterns)
+}
+
+// validateDocument validates the decoded document and returns the given document issues followed by the schema
+// and the semantic check issues. Schema issues of unknown properties and enum values get suggestions.
+// The severity and document index of the returned issues are set, their source location is not.
+func (v JSONSchemaValidator) validateDocument(m interface{}, documentIdx int, documentIssues []ValidationIssue, warningPatterns []string) ([]ValidationIssue, error) {
+	rules, err := NewWarningRules(warningPatterns...)
+	if err != nil {
+		return nil, err
 	}
+	rules = v.warningRules.Merge(rules)
 
+	issues := documentIssues
 	if err = v.schema.ValidateInterface(m); err != nil {
 		validationErr := &jsonschema.ValidationError{}
-		if errors.As(err, &validationErr) {
-			warns, errs = collectIssues(*validationErr, warningPatterns)
-			return warns, errs, nil
+		if !errors.As(err, &validationErr) {
+			return nil, err
+		}
+		issues = recursivelyCollectIssues(*validationErr, issues, v.allAlternatives)
+		for i := len(documentIssues); i < len(issues); i++ {
+			issues[i].Suggestions = v.schemaIndex.suggestions(issues[i], m)
 		}
-		return nil, nil, err
 	}
+	schemaEnd := len(issues)
+	for _, check := range v.semanticChecks {
+		issues = append(issues, check(m)...)
+	}
+	issues = dropSupersededSchemaIssues(issues, len(documentIssues), schemaEnd)
 
-	return nil, nil, nil
+	for i, issue := range issues {
+		issues[i].Document = documentIdx
+		issues[i].Severity = SeverityError
+		if rules.IsWarning(issue) {
+			issues[i].Severity = SeverityWarning
+		}
+	}
+
+	return issues, nil
 }
 
-func collectIssues(err jsonschema.ValidationError, warningPatterns []string) (warnings []string, errors []string) {
-	var issues []string
-	issues = recursivelyCollectIssues(err, issues)
+// locateIssues sets the source position of the issues on a best effort basis.
+// The source is only parsed if there are issues to locate.
+func locateIssues(issues []ValidationIssue, source []byte) {
+	if source == nil || len(issues) == 0 {
+		return
+	}
+	roots, err := parseNodeTrees(source)
+	if err != nil {
+		return
+	}
+	locateIssuesInRoots(issues, roots)
+}
 
-	for _, issue := range issues {
-		isWarning := false
-		for _, pattern := range warningPatterns {
-			re := regexp.MustCompile(pattern)
-			if re.MatchString(issue) {
-				isWarning = true
-				break
-			}
+func locateIssuesInRoots(issues []ValidationIssue, roots []*yamlv3.Node) {
+	for i := range issues {
+		if issues[i].Line != 0 || issues[i].Document >= len(roots) {
+			continue
 		}
-		if isWarning {
-			warnings = append(warnings, issue)
-		} else {
-			errors = append(errors, issue)
+		sites := locateSites(roots[issues[i].Document], issues[i].InstancePtr)
+		issues[i].Line, issues[i].Column = sites.use.line, sites.use.column
+		if sites.definition != nil {
+			issues[i].DefinitionLine, issues[i].DefinitionColumn = sites.definition.line, sites.definition.column
 		}
 	}
+}
+
+func decodeJSON(r io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
 
-	return warnings, errors
+	var m interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the top-level value")
+	}
+
+	return m, nil
 }
 
-func recursivelyCollectIssues(err jsonschema.ValidationError, issues []string) []string {
+// recursivelyCollectIssues flattens the leaf errors of the validation error tree into issues.
+// Unless allAlternatives is set, only the best matching branch of a failed oneOf or anyOf is followed.
+func recursivelyCollectIssues(err jsonschema.ValidationError, issues []ValidationIssue, allAlternatives bool) []ValidationIssue {
 	if len(err.Causes) == 0 {
-		issues = append(issues, fmt.Sprintf("I[%s] S[%s] %s", err.InstancePtr, err.SchemaPtr, err.Message))
+		issues = append(issues, ValidationIssue{
+			InstancePtr: err.InstancePtr,
+			SchemaPtr:   err.SchemaPtr,
+			Message:     err.Message,
+			Keyword:     keywordFromSchemaPtr(err.SchemaPtr),
+		})
 		return issues
 	}
 
+	if !allAlternatives && isAlternativesKeyword(keywordFromSchemaPtr(err.SchemaPtr)) {
+		return recursivelyCollectIssues(*bestMatchingBranch(err), issues, allAlternatives)
+	}
+
 	for _, cause := range err.Causes {
-		issues = recursivelyCollectIssues(*cause, issues)
+		issues = recursivelyCollectIssues(*cause, issues, allAlternatives)
 	}
 
 	return issues
 }
 
-func recursiveJSONMarshallable(source interface{}) (interface{}, error) {
+// recursiveJSONMarshallable converts the YAML decoded value into a JSON compatible value.
+// Mapping entries with non-string keys are left out of the converted value, nonStringKeyIssues reports them.
+func recursiveJSONMarshallable(source interface{}) interface{} {
 	if array, ok := source.([]interface{}); ok {
 		var convertedArray []interface{}
 		for _, element := range array {
-			convertedValue, err := recursiveJSONMarshallable(element)
-			if err != nil {
-				return nil, err
-			}
-			convertedArray = append(convertedArray, convertedValue)
+			convertedArray = append(convertedArray, recursiveJSONMarshallable(element))
 		}
-		return convertedArray, nil
+		return convertedArray
 	}
 
 	if interfaceToInterfaceMap, ok := source.(map[interface{}]interface{}); ok {
 		target := map[string]interface{}{}
 		for key, value := range interfaceToInterfaceMap {
-			strKey, ok := key.(string)
-			if !ok {
-				return nil, fmt.Errorf("failed to convert map key from type interface{} to string")
-			}
-
-			convertedValue, err := recursiveJSONMarshallable(value)
-			if err != nil {
-				return nil, err
+			if strKey, ok := key.(string); ok {
+				target[strKey] = recursiveJSONMarshallable(value)
 			}
-			target[strKey] = convertedValue
 		}
-		return target, nil
+		return target
 	}
 
 	if stringToInterfaceMap, ok := source.(map[string]interface{}); ok {
 		target := map[string]interface{}{}
 		for key, value := range stringToInterfaceMap {
-			convertedValue, err := recursiveJSONMarshallable(value)
-			if err != nil {
-				return nil, err
-			}
-			target[key] = convertedValue
+			target[key] = recursiveJSONMarshallable(value)
 		}
-		return target, nil
+		return target
 	}
 
-	return source, nil
+	return source
 }
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+const stepJSON = `{
+  "title": "Script",
+  "summary": "Run any custom script you want.",
+  "website": "https://github.com/bitrise-io/steps-script",
+  "source_code_url": "https://github.com/bitrise-io/steps-script",
+  "support_url": "https://github.com/bitrise-io/steps-script/issues",
+  "inputs": [
+    {
+      "content": "",
+      "opts": {
+        "title": 1,
+        "summary": "Type your script here."
+      }
+    }
+  ]
+}`
+
+func TestJSONSchemaValidator_ValidateJSON(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	want := []ValidationIssue{{
+		InstancePtr: "#/inputs/0/opts/title",
+		SchemaPtr:   "#/definitions/EnvVarOpts/properties/title/type",
+		Message:     "expected string, but got number",
+		Keyword:     "type",
+		Severity:    SeverityError,
+		Line:        11,
+		Column:      9,
+	}}
+
+	issues, err := v.ValidateJSON([]byte(stepJSON))
+	if err != nil {
+		t.Fatalf("ValidateJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateJSON() got = %#v, want %#v", issues, want)
+	}
+
+	issues, err = v.ValidateReader(strings.NewReader(stepJSON))
+	if err != nil {
+		t.Fatalf("ValidateReader() error = %v", err)
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ValidateReader() got = %#v, want %#v", issues, want)
+	}
+
+	if _, err := v.ValidateJSON([]byte(stepJSON + "{}")); err == nil {
+		t.Errorf("expected error for trailing data")
+	}
+	if _, err := v.ValidateJSON([]byte(`{"title": `)); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestJSONSchemaValidator_ValidateValue(t *testing.T) {
+	v, err := NewJSONSchemaValidator(schemas.StepSchema)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	value := map[string]interface{}{
+		"title":           "Script",
+		"summary":         "Run any custom script you want.",
+		"website":         "https://github.com/bitrise-io/steps-script",
+		"source_code_url": "https://github.com/bitrise-io/steps-script",
+		"support_url":     "https://github.com/bitrise-io/steps-script/issues",
+		"timeout":         json.Number("12345678901234567890"),
+	}
+	issues, err := v.ValidateValue(value)
+	if err != nil {
+		t.Fatalf("ValidateValue() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("ValidateValue() got issues = %v", issues)
+	}
+
+	value["timeout"] = json.Number("1.5")
+	issues, err = v.ValidateValue(value)
+	if err != nil {
+		t.Fatalf("ValidateValue() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].InstancePtr != "#/timeout" || issues[0].Line != 0 {
+		t.Errorf("ValidateValue() got issues = %v", issues)
+	}
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// WarningRules is a compiled set of warning patterns.
+// Issues matching any of the patterns in their String form are reported with SeverityWarning.
+// The same rules can be reused across any number of validations.
+type WarningRules struct {
+	patterns []*regexp.Regexp
+}
+
+// NewWarningRules compiles the given warning patterns, it returns an error for the first invalid pattern.
+func NewWarningRules(patterns ...string) (WarningRules, error) {
+	var rules WarningRules
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return WarningRules{}, fmt.Errorf("invalid warning pattern %q: %w", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	return rules, nil
+}
+
+// Merge returns a rule set containing the patterns of both rule sets.
+func (r WarningRules) Merge(other WarningRules) WarningRules {
+	if len(other.patterns) == 0 {
+		return r
+	}
+	patterns := make([]*regexp.Regexp, 0, len(r.patterns)+len(other.patterns))
+	patterns = append(patterns, r.patterns...)
+	patterns = append(patterns, other.patterns...)
+	return WarningRules{patterns: patterns}
+}
+
+// IsWarning tells if the given issue matches any of the warning patterns.
+func (r WarningRules) IsWarning(issue ValidationIssue) bool {
+	if len(r.patterns) == 0 {
+		return false
+	}
+	str := issue.String()
+	for _, re := range r.patterns {
+		if re.MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
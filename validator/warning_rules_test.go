@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"testing"
+
+	schemas "github.com/bitrise-io/bitrise-json-schemas"
+)
+
+func TestNewWarningRules(t *testing.T) {
+	if _, err := NewWarningRules(`I\[#\]`, `S[#/required`); err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+
+	rules, err := NewWarningRules(`S\[#/required\]`)
+	if err != nil {
+		t.Fatalf("NewWarningRules() error = %v", err)
+	}
+	if !rules.IsWarning(ValidationIssue{InstancePtr: "#", SchemaPtr: "#/required", Message: "missing properties"}) {
+		t.Errorf("expected matching issue to be a warning")
+	}
+	if rules.IsWarning(ValidationIssue{InstancePtr: "#/title", SchemaPtr: "#/properties/title/type", Message: "expected string"}) {
+		t.Errorf("expected not matching issue not to be a warning")
+	}
+
+	other, err := NewWarningRules(`S\[#/properties/title/type\]`)
+	if err != nil {
+		t.Fatalf("NewWarningRules() error = %v", err)
+	}
+	if !rules.Merge(other).IsWarning(ValidationIssue{InstancePtr: "#/title", SchemaPtr: "#/properties/title/type", Message: "expected string"}) {
+		t.Errorf("expected merged rules to match")
+	}
+}
+
+func TestJSONSchemaValidator_WithWarningRules(t *testing.T) {
+	rules, err := NewWarningRules(`S\[#/required\]`)
+	if err != nil {
+		t.Fatalf("NewWarningRules() error = %v", err)
+	}
+	v, err := NewJSONSchemaValidator(schemas.StepSchema, WithWarningRules(rules))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+
+	stepYML := `
+title: Script
+summary: Run any custom script you want.
+website: https://github.com/bitrise-io/steps-script
+support_url: https://github.com/bitrise-io/steps-script/issues
+`
+	for i := 0; i < 2; i++ {
+		warnings, errors, err := v.Validate(stepYML)
+		if err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		if len(warnings) != 1 || len(errors) != 0 {
+			t.Errorf("Validate() got warnings = %v, errors = %v", warnings, errors)
+		}
+	}
+
+	if _, _, err := v.Validate(stepYML, `(`); err == nil {
+		t.Errorf("expected error for invalid warning pattern")
+	}
+}
@@ -0,0 +1,126 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+const KeywordRecursiveWorkflow = "recursiveWorkflow"
+
+var _ = describeRules(map[string]string{
+	KeywordRecursiveWorkflow: "Workflow running itself through its before_run or after_run workflows",
+})
+
+// ExpandedStep is a step run by an expanded workflow, Workflow is the name of the workflow defining the step.
+type ExpandedStep struct {
+	Step
+	Workflow string
+}
+
+// ExpandWorkflow returns the steps the workflow of the bitrise.yml runs, in order: the steps of its before_run
+// workflows, its own steps and the steps of its after_run workflows, with the before_run and after_run workflows
+// expanded recursively. Step bundle references are returned as they are.
+//
+// A recursive chain is reported as an issue at the before_run or after_run item closing it, and is not expanded again.
+// Undefined workflows are skipped, those are reported by CheckBitriseYMLReferences.
+func ExpandWorkflow(document interface{}, name string) ([]ExpandedStep, []ValidationIssue, error) {
+	model := LoadBitriseYML(document)
+	if _, ok := model.Workflows[name]; !ok {
+		return nil, nil, fmt.Errorf("workflow %q is not defined", name)
+	}
+
+	var steps []ExpandedStep
+	var issues []ValidationIssue
+	reported := map[string]bool{}
+	walkWorkflowChain(model, name, func(workflowName string, workflow Workflow) {
+		for _, step := range workflow.Steps {
+			steps = append(steps, ExpandedStep{Step: step, Workflow: workflowName})
+		}
+	}, func(reference Reference, cycle []string) {
+		if !reported[reference.Ptr] {
+			reported[reference.Ptr] = true
+			issues = append(issues, recursiveWorkflowIssue(reference, cycle))
+		}
+	})
+
+	return steps, issues, nil
+}
+
+// CheckWorkflowRecursion reports the recursive before_run and after_run chains of the workflows,
+// like a workflow running itself through its before_run workflow. Every cycle is reported once,
+// with the full chain of workflows, at the before_run or after_run item closing it.
+func CheckWorkflowRecursion(document interface{}) []ValidationIssue {
+	model := LoadBitriseYML(document)
+
+	runs := map[string][]string{}
+	// referencePtrs are the JSON pointers of the first before_run or after_run items of the graph edges.
+	referencePtrs := map[string]map[string]string{}
+	for _, name := range model.WorkflowNames() {
+		workflow := model.Workflows[name]
+		referencePtrs[name] = map[string]string{}
+		for _, reference := range append(append([]Reference{}, workflow.BeforeRun...), workflow.AfterRun...) {
+			if _, ok := model.Workflows[reference.Name]; !ok {
+				continue
+			}
+			if _, ok := referencePtrs[name][reference.Name]; ok {
+				continue
+			}
+			runs[name] = append(runs[name], reference.Name)
+			referencePtrs[name][reference.Name] = reference.Ptr
+		}
+	}
+
+	var issues []ValidationIssue
+	for _, cycle := range dependencyCycles(model.WorkflowNames(), runs) {
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		issues = append(issues, recursiveWorkflowIssue(Reference{Name: to, Ptr: referencePtrs[from][to]}, cycle))
+	}
+	return issues
+}
+
+// walkWorkflowChain calls run with the workflows the workflow runs, in the order they run.
+// recursion is called with the before_run or after_run items closing a recursive chain, and the cycle they close.
+func walkWorkflowChain(model *BitriseYML, name string, run func(name string, workflow Workflow), recursion func(reference Reference, cycle []string)) {
+	var walk func(name string, stack []string)
+	walk = func(name string, stack []string) {
+		workflow := model.Workflows[name]
+		stack = append(stack, name)
+		walkReferences := func(references []Reference) {
+			for _, reference := range references {
+				if _, ok := model.Workflows[reference.Name]; !ok {
+					continue
+				}
+				if idx := indexOfString(stack, reference.Name); idx != -1 {
+					if recursion != nil {
+						recursion(reference, append(append([]string{}, stack[idx:]...), reference.Name))
+					}
+					continue
+				}
+				walk(reference.Name, stack)
+			}
+		}
+
+		walkReferences(workflow.BeforeRun)
+		run(name, workflow)
+		walkReferences(workflow.AfterRun)
+	}
+	walk(name, nil)
+}
+
+func recursiveWorkflowIssue(reference Reference, cycle []string) ValidationIssue {
+	return ValidationIssue{
+		InstancePtr: reference.Ptr,
+		SchemaPtr:   semanticSchemaPtr(KeywordRecursiveWorkflow),
+		Message:     fmt.Sprintf("recursive workflow chain: %s", strings.Join(cycle, " -> ")),
+		Keyword:     KeywordRecursiveWorkflow,
+	}
+}
+
+func indexOfString(values []string, value string) int {
+	for idx, v := range values {
+		if v == value {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+const bitriseYMLWithWorkflowChains = `format_version: "13"
+workflows:
+  primary:
+    before_run: [setup, undefined]
+    after_run: [report]
+    steps:
+    - git-clone@8: {}
+    - with:
+        container: golang
+        steps:
+        - script@1:
+            title: Test
+    - bundle::deploy: {}
+  setup:
+    steps:
+    - activate-ssh-key@4: {}
+  report:
+    before_run: [notify]
+    steps:
+    - deploy-to-bitrise-io@2: {}
+  notify:
+    steps:
+    - slack@3: {}
+  a:
+    before_run: [b]
+  b:
+    after_run: [c]
+  c:
+    before_run: [a]
+    after_run: [c]
+`
+
+func TestExpandWorkflow(t *testing.T) {
+	steps, issues, err := ExpandWorkflow(decodeTestYAML(t, bitriseYMLWithWorkflowChains), "primary")
+	if err != nil {
+		t.Fatalf("ExpandWorkflow() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("ExpandWorkflow() issues = %v, want none", issues)
+	}
+
+	want := []ExpandedStep{
+		{Step: Step{Ptr: "#/workflows/setup/steps/0", ID: "activate-ssh-key@4"}, Workflow: "setup"},
+		{Step: Step{Ptr: "#/workflows/primary/steps/0", ID: "git-clone@8"}, Workflow: "primary"},
+		{Step: Step{Ptr: "#/workflows/primary/steps/1/with/steps/0", ID: "script@1"}, Workflow: "primary"},
+		{Step: Step{Ptr: "#/workflows/primary/steps/2", ID: "bundle::deploy"}, Workflow: "primary"},
+		{Step: Step{Ptr: "#/workflows/notify/steps/0", ID: "slack@3"}, Workflow: "notify"},
+		{Step: Step{Ptr: "#/workflows/report/steps/0", ID: "deploy-to-bitrise-io@2"}, Workflow: "report"},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("ExpandWorkflow() =\n%+v\nwant\n%+v", steps, want)
+	}
+}
+
+func TestExpandWorkflow_Recursion(t *testing.T) {
+	_, issues, err := ExpandWorkflow(decodeTestYAML(t, bitriseYMLWithWorkflowChains), "a")
+	if err != nil {
+		t.Fatalf("ExpandWorkflow() error = %v", err)
+	}
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/workflows/c/before_run/0", SchemaPtr: semanticSchemaPtr(KeywordRecursiveWorkflow), Message: "recursive workflow chain: a -> b -> c -> a", Keyword: KeywordRecursiveWorkflow},
+		{InstancePtr: "#/workflows/c/after_run/0", SchemaPtr: semanticSchemaPtr(KeywordRecursiveWorkflow), Message: "recursive workflow chain: c -> c", Keyword: KeywordRecursiveWorkflow},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ExpandWorkflow() issues =\n%#v\nwant\n%#v", issues, want)
+	}
+
+	if _, _, err := ExpandWorkflow(decodeTestYAML(t, bitriseYMLWithWorkflowChains), "undefined"); err == nil {
+		t.Errorf("ExpandWorkflow() error = nil, want an undefined workflow error")
+	}
+}
+
+func TestCheckWorkflowRecursion(t *testing.T) {
+	issues := CheckWorkflowRecursion(decodeTestYAML(t, bitriseYMLWithWorkflowChains))
+
+	want := []ValidationIssue{
+		{InstancePtr: "#/workflows/c/before_run/0", SchemaPtr: semanticSchemaPtr(KeywordRecursiveWorkflow), Message: "recursive workflow chain: a -> b -> c -> a", Keyword: KeywordRecursiveWorkflow},
+		{InstancePtr: "#/workflows/c/after_run/0", SchemaPtr: semanticSchemaPtr(KeywordRecursiveWorkflow), Message: "recursive workflow chain: c -> c", Keyword: KeywordRecursiveWorkflow},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckWorkflowRecursion() =\n%#v\nwant\n%#v", issues, want)
+	}
+}